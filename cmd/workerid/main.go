@@ -0,0 +1,416 @@
+// Command workerid 用于查看和管理 Redis 中的 WorkerID 池
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"libx.net/workerid"
+)
+
+const usage = `Usage: workerid [flags] <command> [args]
+
+Commands:
+  list                          list every worker ID with its state, expiry and holder
+  stats                         show summary counts of the pool
+  acquire                       acquire a worker ID and print it with its token
+  renew <id> <token>            renew the lease of a worker ID
+  release <id> <token>          release a worker ID
+  revoke [-operator name] [-reason text] <id>
+                                forcibly free a worker ID without its token
+  resize [-force] <bits>        grow or shrink the pool to 2^bits worker IDs
+  audit [-n limit]              show recent administrative operations
+  sweep                         clear token records left by expired leases
+  reservations                  list reserved worker IDs and their static holders
+  unreserve [-operator name] [-reason text] <id>
+                                return a reserved worker ID to the pool
+  reset [-force]                clear every lease and rebuild the pool
+  destroy [-force]              delete every key of the pool, including audit log and reservations
+
+Flags:
+`
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "workerid: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+type cli struct {
+	gen    *workerid.RedisGenerator
+	open   func(ctx context.Context) (*workerid.RedisGenerator, error)
+	output string
+	out    io.Writer
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("workerid", flag.ContinueOnError)
+	fs.SetOutput(out)
+	addr := fs.String("addr", envOr("WORKERID_REDIS_ADDR", "localhost:6379"), "Redis server address")
+	password := fs.String("password", os.Getenv("WORKERID_REDIS_PASSWORD"), "Redis password")
+	db := fs.Int("db", 0, "Redis database number")
+	cluster := fs.String("cluster", "", "cluster name of the worker ID pool (required)")
+	prefix := fs.String("prefix", "workerid", "key prefix (namespace) of the worker ID pool")
+	mode := fs.String("mode", "eager", "storage mode of the pool: eager, lazy or bitmap, used when the pool has no metadata")
+	bits := fs.Uint("bits", 9, "worker bits of the pool, used when the pool has no metadata")
+	dcBits := fs.Uint("dc-bits", 0, "datacenter bits of composite worker IDs, -bits is then the machine bits")
+	dc := fs.Uint("dc", 0, "datacenter ID of the pool, used with -dc-bits")
+	lease := fs.Duration("lease", 5*time.Minute, "lease time used by acquire and renew")
+	output := fs.String("o", "table", "output format: table or json")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("missing command")
+	}
+	if *cluster == "" {
+		return errors.New("-cluster is required")
+	}
+	if *output != "table" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     *addr,
+		Password: *password,
+		DB:       *db,
+	})
+	defer client.Close()
+
+	options := []workerid.Option{
+		workerid.WithWorkerBits(*bits),
+		workerid.WithMaxLeaseTime(*lease),
+		workerid.WithKeyPrefix(*prefix),
+		workerid.WithPoolMode(workerid.PoolMode(*mode)),
+	}
+	if *dcBits > 0 {
+		options = append(options, workerid.WithDatacenter(uint32(*dc), *dcBits, *bits))
+	}
+	// 只打开已有的池，池的范围和存储方式以元数据为准，避免使用默认的 -bits 初始化或扩大服务正在使用的池
+	open := func(ctx context.Context) (*workerid.RedisGenerator, error) {
+		return workerid.OpenRedisGenerator(ctx, client, *cluster, options...)
+	}
+	gen, err := open(ctx)
+	if err != nil {
+		return err
+	}
+
+	c := &cli{gen: gen, open: open, output: *output, out: out}
+	command, cmdArgs := fs.Arg(0), fs.Args()[1:]
+	switch command {
+	case "list":
+		return c.list(ctx)
+	case "stats":
+		return c.stats(ctx)
+	case "acquire":
+		return c.acquire()
+	case "renew":
+		return c.renew(cmdArgs)
+	case "release":
+		return c.release(cmdArgs)
+	case "revoke":
+		return c.revoke(ctx, cmdArgs)
+	case "resize":
+		return c.resize(ctx, cmdArgs)
+	case "audit":
+		return c.audit(ctx, cmdArgs)
+	case "sweep":
+		return c.sweep(ctx)
+	case "reservations":
+		return c.reservations(ctx)
+	case "unreserve":
+		return c.unreserve(ctx, cmdArgs)
+	case "reset":
+		return c.reset(ctx, command, cmdArgs, "reset", c.gen.Reset)
+	case "destroy":
+		return c.reset(ctx, command, cmdArgs, "destroyed", c.gen.Destroy)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func (c *cli) list(ctx context.Context) error {
+	leases, err := c.gen.ListLeases(ctx)
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return c.printJSON(leases)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATE\tEXPIRE AT\tHOSTNAME\tPOD\tPID\tVERSION")
+	for _, lease := range leases {
+		expireAt := "-"
+		if !lease.ExpireAt.IsZero() {
+			expireAt = lease.ExpireAt.Format(time.RFC3339)
+		} else if !lease.ReusableAt.IsZero() {
+			expireAt = "reusable at " + lease.ReusableAt.Format(time.RFC3339)
+		}
+		hostname, pod, pid, version := "-", "-", "-", "-"
+		if h := lease.Holder; h != nil {
+			hostname, pod, version = orDash(h.Hostname), orDash(h.Pod), orDash(h.Version)
+			if h.PID > 0 {
+				pid = strconv.Itoa(h.PID)
+			}
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			lease.WorkerID, lease.State, expireAt, hostname, pod, pid, version)
+	}
+	return w.Flush()
+}
+
+func (c *cli) stats(ctx context.Context) error {
+	stats, err := c.gen.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return c.printJSON(stats)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "CLUSTER\t%s\n", stats.Cluster)
+	fmt.Fprintf(w, "TOTAL\t%d\n", stats.Total)
+	fmt.Fprintf(w, "FREE\t%d\n", stats.Free)
+	fmt.Fprintf(w, "LEASED\t%d\n", stats.Leased)
+	fmt.Fprintf(w, "EXPIRED\t%d\n", stats.Expired)
+	fmt.Fprintf(w, "QUARANTINED\t%d\n", stats.Quarantined)
+	fmt.Fprintf(w, "RESERVED\t%d\n", stats.Reserved)
+	return w.Flush()
+}
+
+func (c *cli) acquire() error {
+	holder := workerid.LocalHolder()
+	holder.Labels = map[string]string{"source": "cli"}
+	workerID, token, err := c.gen.GetIDWithHolder(holder)
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return c.printJSON(map[string]any{"worker_id": workerID, "token": token})
+	}
+	fmt.Fprintf(c.out, "%d\t%s\n", workerID, token)
+	return nil
+}
+
+func (c *cli) renew(args []string) error {
+	workerID, token, err := parseIDAndToken(args)
+	if err != nil {
+		return err
+	}
+	if err := c.gen.Renew(workerID, token); err != nil {
+		return err
+	}
+	return c.done("renewed", workerID)
+}
+
+func (c *cli) release(args []string) error {
+	workerID, token, err := parseIDAndToken(args)
+	if err != nil {
+		return err
+	}
+	if err := c.gen.Release(workerID, token); err != nil {
+		return err
+	}
+	return c.done("released", workerID)
+}
+
+func (c *cli) revoke(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("revoke", flag.ContinueOnError)
+	fs.SetOutput(c.out)
+	operator := fs.String("operator", currentUser(), "operator recorded in the audit log")
+	reason := fs.String("reason", "", "reason recorded in the audit log")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: revoke [-operator name] [-reason text] <id>")
+	}
+	workerID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid worker ID %q", fs.Arg(0))
+	}
+	if err := c.gen.Revoke(ctx, workerID, *operator, *reason); err != nil {
+		return err
+	}
+	return c.done("revoked", workerID)
+}
+
+func (c *cli) resize(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("resize", flag.ContinueOnError)
+	fs.SetOutput(c.out)
+	force := fs.Bool("force", false, "reclaim worker IDs that are still leased")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: resize [-force] <bits>")
+	}
+	bits, err := strconv.ParseUint(fs.Arg(0), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid worker bits %q", fs.Arg(0))
+	}
+	if err := c.gen.Resize(ctx, uint(bits), *force); err != nil {
+		return err
+	}
+	// 重新打开池以读取新的范围
+	if c.gen, err = c.open(ctx); err != nil {
+		return err
+	}
+	return c.stats(ctx)
+}
+
+func (c *cli) audit(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	fs.SetOutput(c.out)
+	limit := fs.Int("n", 20, "number of entries to show, 0 for all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	entries, err := c.gen.AuditLog(ctx, *limit)
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return c.printJSON(entries)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tACTION\tID\tOPERATOR\tREASON")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", time.Unix(entry.Time, 0).Format(time.RFC3339),
+			entry.Action, entry.WorkerID, entry.Operator, orDash(entry.Reason))
+	}
+	return w.Flush()
+}
+
+func (c *cli) sweep(ctx context.Context) error {
+	result, err := c.gen.Sweep(ctx)
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return c.printJSON(result)
+	}
+	fmt.Fprintf(c.out, "reclaimed %d expired worker IDs %v\n", len(result.Reclaimed), result.Reclaimed)
+	return nil
+}
+
+func (c *cli) reservations(ctx context.Context) error {
+	reservations, err := c.gen.Reservations(ctx)
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return c.printJSON(reservations)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME")
+	for _, reservation := range reservations {
+		fmt.Fprintf(w, "%d\t%s\n", reservation.WorkerID, orDash(reservation.Name))
+	}
+	return w.Flush()
+}
+
+func (c *cli) reset(ctx context.Context, command string, args []string, action string,
+	reset func(context.Context, bool) error) error {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(c.out)
+	force := fs.Bool("force", false, "clear worker IDs that are still leased")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := reset(ctx, *force); err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return c.printJSON(map[string]any{"cluster": c.gen.Cluster(), "result": action})
+	}
+	fmt.Fprintf(c.out, "worker ID pool of cluster %s %s\n", c.gen.Cluster(), action)
+	return nil
+}
+
+func (c *cli) unreserve(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("unreserve", flag.ContinueOnError)
+	fs.SetOutput(c.out)
+	operator := fs.String("operator", currentUser(), "operator recorded in the audit log")
+	reason := fs.String("reason", "", "reason recorded in the audit log")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: unreserve [-operator name] [-reason text] <id>")
+	}
+	workerID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid worker ID %q", fs.Arg(0))
+	}
+	if err := c.gen.Unreserve(ctx, workerID, *operator, *reason); err != nil {
+		return err
+	}
+	return c.done("unreserved", workerID)
+}
+
+func (c *cli) done(action string, workerID int64) error {
+	if c.output == "json" {
+		return c.printJSON(map[string]any{"worker_id": workerID, "result": action})
+	}
+	fmt.Fprintf(c.out, "worker ID %d %s\n", workerID, action)
+	return nil
+}
+
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func parseIDAndToken(args []string) (int64, string, error) {
+	if len(args) != 2 {
+		return 0, "", errors.New("expected <id> <token>")
+	}
+	workerID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid worker ID %q", args[0])
+	}
+	return workerID, args[1], nil
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"libx.net/workerid"
+)
+
+func runCLI(t *testing.T, addr string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	args = append([]string{"-addr", addr, "-cluster", "cli-cluster"}, args...)
+	err := run(context.Background(), args, &out)
+	return out.String(), err
+}
+
+func TestCLI(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	// 池由服务创建，CLI 使用池的元数据而不是默认的 -bits
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	if _, err := workerid.NewRedisGenerator(client, "cli-cluster", workerid.WithWorkerBits(2)); err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	// acquire 输出 WorkerID 和 Token
+	out, err := runCLI(t, mr.Addr(), "-o", "json", "acquire")
+	if err != nil {
+		t.Fatalf("acquire 失败: %v", err)
+	}
+	var acquired struct {
+		WorkerID int64  `json:"worker_id"`
+		Token    string `json:"token"`
+	}
+	if err := json.Unmarshal([]byte(out), &acquired); err != nil {
+		t.Fatalf("解析 acquire 输出失败: %v, 输出: %s", err, out)
+	}
+	if len(acquired.Token) != 22 {
+		t.Errorf("Token 长度应该为 22, 实际长度: %d", len(acquired.Token))
+	}
+	id := strconv.FormatInt(acquired.WorkerID, 10)
+
+	if _, err := runCLI(t, mr.Addr(), "renew", id, acquired.Token); err != nil {
+		t.Errorf("renew 失败: %v", err)
+	}
+
+	// list 的 JSON 输出包含持有者信息
+	out, err = runCLI(t, mr.Addr(), "-o", "json", "list")
+	if err != nil {
+		t.Fatalf("list 失败: %v", err)
+	}
+	var leases []workerid.Lease
+	if err := json.Unmarshal([]byte(out), &leases); err != nil {
+		t.Fatalf("解析 list 输出失败: %v", err)
+	}
+	if len(leases) != 4 {
+		t.Fatalf("租约数量应该为 4, 实际值: %d", len(leases))
+	}
+	lease := leases[acquired.WorkerID]
+	if lease.State != workerid.LeaseLeased || lease.Holder == nil || lease.Holder.Labels["source"] != "cli" {
+		t.Errorf("租约信息不正确: %+v", lease)
+	}
+
+	// stats 的表格输出
+	out, err = runCLI(t, mr.Addr(), "stats")
+	if err != nil {
+		t.Fatalf("stats 失败: %v", err)
+	}
+	if fields := strings.Fields(out); !strings.Contains(strings.Join(fields, " "), "TOTAL 4 FREE 3 LEASED 1") {
+		t.Errorf("stats 输出不正确:\n%s", out)
+	}
+
+	// revoke 后 renew 失败，并留下审计记录
+	if _, err := runCLI(t, mr.Addr(), "revoke", "-operator", "alice", "-reason", "test", id); err != nil {
+		t.Fatalf("revoke 失败: %v", err)
+	}
+	if _, err := runCLI(t, mr.Addr(), "renew", id, acquired.Token); err == nil {
+		t.Error("revoke 后 renew 应该失败")
+	}
+	out, err = runCLI(t, mr.Addr(), "audit")
+	if err != nil {
+		t.Fatalf("audit 失败: %v", err)
+	}
+	if !strings.Contains(out, "revoke") || !strings.Contains(out, "alice") {
+		t.Errorf("audit 输出不正确:\n%s", out)
+	}
+
+	// resize 扩容后池大小变化
+	out, err = runCLI(t, mr.Addr(), "-o", "json", "resize", "3")
+	if err != nil {
+		t.Fatalf("resize 失败: %v", err)
+	}
+	var stats workerid.PoolStats
+	if err := json.Unmarshal([]byte(out), &stats); err != nil {
+		t.Fatalf("解析 resize 输出失败: %v", err)
+	}
+	if stats.Total != 8 {
+		t.Errorf("扩容后 ID 总数应该为 8, 实际值: %d", stats.Total)
+	}
+
+	out, err = runCLI(t, mr.Addr(), "sweep")
+	if err != nil {
+		t.Fatalf("sweep 失败: %v", err)
+	}
+	if !strings.HasPrefix(out, "reclaimed 0 expired worker IDs") {
+		t.Errorf("sweep 输出不正确: %s", out)
+	}
+
+	out, err = runCLI(t, mr.Addr(), "reservations")
+	if err != nil {
+		t.Fatalf("reservations 失败: %v", err)
+	}
+	if strings.TrimSpace(out) != "ID  NAME" {
+		t.Errorf("reservations 输出不正确:\n%s", out)
+	}
+	if _, err := runCLI(t, mr.Addr(), "unreserve", "0"); !errors.Is(err, workerid.ErrNotReserved) {
+		t.Errorf("未被预留的 ID unreserve 应该返回 ErrNotReserved, 实际值: %v", err)
+	}
+
+	// reset 在存在有效租约时需要 -force
+	if _, err := runCLI(t, mr.Addr(), "acquire"); err != nil {
+		t.Fatalf("acquire 失败: %v", err)
+	}
+	if _, err := runCLI(t, mr.Addr(), "reset"); err == nil {
+		t.Error("存在有效租约时 reset 应该失败")
+	}
+	if _, err := runCLI(t, mr.Addr(), "reset", "-force"); err != nil {
+		t.Errorf("reset -force 失败: %v", err)
+	}
+	if _, err := runCLI(t, mr.Addr(), "destroy"); err != nil {
+		t.Errorf("destroy 失败: %v", err)
+	}
+	if keys := mr.Keys(); len(keys) != 0 {
+		t.Errorf("destroy 后不应留下任何键, 实际值: %v", keys)
+	}
+
+	// 错误的参数
+	if _, err := runCLI(t, mr.Addr(), "unknown"); err == nil {
+		t.Error("未知命令应该返回错误")
+	}
+	if _, err := runCLI(t, mr.Addr(), "release", "1"); err == nil {
+		t.Error("缺少 Token 时 release 应该返回错误")
+	}
+}
@@ -0,0 +1,275 @@
+package workerid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DegradedMode Redis 不可用时的降级配置
+type DegradedMode struct {
+	// CacheFile 记录最近一次分配到的租约的本地文件，Redis 不可用时优先使用其中的 WorkerID
+	CacheFile string
+	// StaticWorkerID 没有可用的缓存时使用的 WorkerID，为 nil 时不使用
+	StaticWorkerID *int64
+	// FailureThreshold 连续失败多少次后断路器打开，默认为 3
+	FailureThreshold int
+	// Cooldown 断路器打开后多久再次尝试访问 Redis，默认为 30 秒
+	Cooldown time.Duration
+}
+
+// breaker 连续失败达到阈值后在冷却时间内拒绝访问 Redis，冷却结束后允许再次尝试
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// allow 判断是否允许访问 Redis，nil 表示未开启断路器
+func (b *breaker) allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !time.Now().Before(b.openUntil)
+}
+
+// record 记录一次访问的结果，只有可重试的临时错误才计为失败
+func (b *breaker) record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !IsRetryable(err) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// degraded 降级模式的运行状态
+type degraded struct {
+	DegradedMode
+
+	// initMu 保护 initPending，补充初始化期间会访问 Redis，不能阻塞使用 mu 的操作
+	initMu      sync.Mutex
+	initPending bool
+
+	mu sync.Mutex
+	// unverified 降级期间发放、尚未在 Redis 中确认的租约，WorkerID -> Token
+	unverified map[int64]string
+}
+
+// isUnavailable 判断错误是否表示 Redis 不可用，此时降级模式使用本地的 WorkerID
+func isUnavailable(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || IsRetryable(err)
+}
+
+// IsUnverified 判断 WorkerID 是否为降级期间发放、尚未在 Redis 中确认的租约。
+// 未确认的租约在 Redis 恢复后的下一次 Renew 时确认，若该 ID 已被其他实例占用则返回 ErrWorkerIDInUse
+func (g *RedisGenerator) IsUnverified(workerID int64) bool {
+	if g.degraded == nil {
+		return false
+	}
+	g.degraded.mu.Lock()
+	defer g.degraded.mu.Unlock()
+	_, ok := g.degraded.unverified[workerID]
+	return ok
+}
+
+// ensureInitialized 降级模式下创建时未能初始化的池，在 Redis 恢复后补充初始化
+func (g *RedisGenerator) ensureInitialized() error {
+	if g.degraded == nil {
+		return nil
+	}
+	g.degraded.initMu.Lock()
+	defer g.degraded.initMu.Unlock()
+	if !g.degraded.initPending {
+		return nil
+	}
+	if err := g.initAvailableIDs(); err != nil {
+		return err
+	}
+	g.degraded.initPending = false
+	return nil
+}
+
+// fallbackID 降级模式下 Redis 不可用时返回缓存或静态配置的 WorkerID，并生成新的 Token 标记为未确认，
+// 否则原样返回 cause
+func (g *RedisGenerator) fallbackID(ctx context.Context, cause error) (int64, string, error) {
+	if g.degraded == nil || !isUnavailable(cause) {
+		return 0, "", cause
+	}
+	workerID := int64(-1)
+	if g.degraded.CacheFile != "" {
+		record, err := readLeaseRecord(g.degraded.CacheFile)
+		if err != nil {
+			g.logger.WarnContext(ctx, "read lease file failed", slog.String("path", g.degraded.CacheFile),
+				slog.Any("error", err))
+		} else if record != nil && record.Pool == g.getIDsKey() {
+			workerID = record.WorkerID
+		}
+	}
+	if workerID < 0 && g.degraded.StaticWorkerID != nil {
+		workerID = *g.degraded.StaticWorkerID
+	}
+	if workerID < 0 {
+		return 0, "", cause
+	}
+	if _, err := g.machineID(workerID); err != nil {
+		return 0, "", err
+	}
+
+	token := generateToken()
+	g.degraded.mu.Lock()
+	if _, ok := g.degraded.unverified[workerID]; ok {
+		g.degraded.mu.Unlock()
+		return 0, "", cause
+	}
+	g.degraded.unverified[workerID] = token
+	g.degraded.mu.Unlock()
+
+	g.logger.WarnContext(ctx, "redis unavailable, using unverified worker ID", slog.Int64("worker_id", workerID),
+		slog.Any("error", cause))
+	return workerID, token, nil
+}
+
+var claimScript = redis.NewScript(`
+	local key = KEYS[1]
+	local tokenKey = KEYS[2]
+	local workerID = ARGV[1]
+	local token = ARGV[2]
+	local now = tonumber(ARGV[3])
+	local lease = tonumber(ARGV[4])
+
+	-- 1. 预留的 ID 或其他实例仍在租约期内的 ID 不能被确认
+	if redis.call('HEXISTS', KEYS[5], workerID) == 1 then
+		return {err="Worker ID in use"}
+	end
+	local tokenStr = redis.call('HGET', tokenKey, workerID)
+	if tokenStr then
+		local colonPos = string.find(tokenStr, ":")
+		local expireAt = colonPos and tonumber(string.sub(tokenStr, colonPos+1))
+		if string.sub(tokenStr, 1, (colonPos or 0)-1) ~= token and expireAt and expireAt > now then
+			return {err="Worker ID in use"}
+		end
+	end
+
+	-- 2. 以降级期间生成的 Token 分配该 ID
+	local newExpire = now + lease
+	redis.call('ZADD', key, newExpire, workerID)
+	redis.call('HSET', tokenKey, workerID, token .. ':' .. newExpire)
+	redis.call('EXPIRE', tokenKey, lease * 3)
+	if ARGV[5] ~= '' then
+		redis.call('HSET', KEYS[3], workerID, ARGV[5])
+		redis.call('EXPIRE', KEYS[3], lease * 3)
+	else
+		redis.call('HDEL', KEYS[3], workerID)
+	end
+	if ARGV[6] == 'bitmap' then
+		redis.call('SETBIT', KEYS[6], workerID, 1)
+	end
+
+	redis.call('PUBLISH', KEYS[4], cjson.encode({type='acquired', worker_id=tonumber(workerID), time=now}))
+	return newExpire
+`)
+
+// reconcile 在 Redis 中确认降级期间发放的租约，该 ID 已被其他实例占用时返回 ErrWorkerIDInUse
+func (g *RedisGenerator) reconcile(ctx context.Context, workerID int64, token string) error {
+	id, err := g.machineID(workerID)
+	if err != nil {
+		return err
+	}
+	holderData := ""
+	if g.holder != nil {
+		data, err := json.Marshal(g.holder)
+		if err != nil {
+			return fmt.Errorf("encode holder failed: %w", err)
+		}
+		holderData = string(data)
+	}
+
+	var expireAt int64
+	keys := []string{g.getIDsKey(), g.getTokenKey(), g.getHolderKey(), g.getEventChannel(), g.getReservedKey(),
+		g.getBitmapKey()}
+	if err := g.ensureInitialized(); err != nil {
+		return fmt.Errorf("initialize available IDs failed: %w", err)
+	}
+	err = g.retry(ctx, OpRenew, func(int) error {
+		now, err := g.getCurrentTime(ctx)
+		if err != nil {
+			return fmt.Errorf("get current time failed: %w", err)
+		}
+		expireAt, err = g.runScript(ctx, claimScript, "claim", keys,
+			id, token, now, g.leaseSeconds, holderData, string(g.poolMode)).Int64()
+		if err != nil {
+			if err.Error() == "Worker ID in use" {
+				return ErrWorkerIDInUse
+			}
+			return fmt.Errorf("reconcile failed: %w", err)
+		}
+		return nil
+	})
+	if isUnavailable(err) {
+		return err
+	}
+
+	// 确认成功或被拒绝后都不再是未确认的租约
+	g.degraded.mu.Lock()
+	delete(g.degraded.unverified, workerID)
+	g.degraded.mu.Unlock()
+	if err != nil {
+		g.logger.ErrorContext(ctx, "unverified worker ID taken by another instance", slog.Int64("worker_id", workerID))
+		return err
+	}
+	g.logger.InfoContext(ctx, "unverified worker ID reconciled", slog.Int64("worker_id", workerID))
+	g.saveLease(ctx, workerID, token, expireAt)
+	return nil
+}
+
+// Reconcile 在 Redis 中确认所有降级期间发放的租约，Redis 仍不可用时返回对应的错误。
+// 已被其他实例占用的 ID 不再视为未确认，返回的错误中包含 ErrWorkerIDInUse
+func (g *RedisGenerator) Reconcile(ctx context.Context) error {
+	if g.degraded == nil {
+		return nil
+	}
+	g.degraded.mu.Lock()
+	leases := make(map[int64]string, len(g.degraded.unverified))
+	for workerID, token := range g.degraded.unverified {
+		leases[workerID] = token
+	}
+	g.degraded.mu.Unlock()
+
+	var errs []error
+	for workerID, token := range leases {
+		if err := g.reconcile(ctx, workerID, token); err != nil {
+			errs = append(errs, fmt.Errorf("reconcile worker ID %d: %w", workerID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// unverifiedToken 返回降级期间发放的租约的 Token
+func (g *RedisGenerator) unverifiedToken(workerID int64) (string, bool) {
+	if g.degraded == nil {
+		return "", false
+	}
+	g.degraded.mu.Lock()
+	defer g.degraded.mu.Unlock()
+	token, ok := g.degraded.unverified[workerID]
+	return token, ok
+}
@@ -0,0 +1,263 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+const loadingError = "LOADING Redis is loading the dataset in memory"
+
+func TestRedisGenerator_DegradedStaticID(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	// 未开启降级模式时 Redis 不可用无法创建
+	mr.SetError(loadingError)
+	if _, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2)); err == nil {
+		t.Fatal("未开启降级模式时 Redis 不可用应该返回错误")
+	}
+
+	staticID := int64(2)
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2),
+		WithDegradedMode(DegradedMode{StaticWorkerID: &staticID, Cooldown: 50 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("降级模式下 Redis 不可用时也应该创建成功: %v", err)
+	}
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("降级模式下 GetID() 失败: %v", err)
+	}
+	if workerID != staticID || !gen.IsUnverified(workerID) {
+		t.Fatalf("应该返回未确认的静态 WorkerID %d, 实际值: %d, 未确认: %v", staticID, workerID, gen.IsUnverified(workerID))
+	}
+	if _, _, err := gen.GetID(); err == nil {
+		t.Error("静态 WorkerID 已被使用时 GetID 应该返回错误")
+	}
+
+	// Redis 恢复且冷却结束后 Renew 确认租约，并补充初始化池
+	mr.SetError("")
+	time.Sleep(100 * time.Millisecond)
+	if err := gen.Renew(workerID, token); err != nil {
+		t.Fatalf("Redis 恢复后 Renew 应该确认租约: %v", err)
+	}
+	if gen.IsUnverified(workerID) {
+		t.Error("确认后不应再是未确认的租约")
+	}
+	stats, err := gen.Stats(context.Background())
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if stats.Total != 4 || stats.Leased != 1 {
+		t.Errorf("池应该有 4 个 ID 且 1 个已分配, 实际值: %+v", stats)
+	}
+	if err := gen.Renew(workerID, token); err != nil {
+		t.Errorf("确认后 Renew 失败: %v", err)
+	}
+	if err := gen.Release(workerID, token); err != nil {
+		t.Errorf("确认后 Release 失败: %v", err)
+	}
+}
+
+func TestRedisGenerator_DegradedPoolMismatch(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	if _, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(3)); err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	// 配置与池不一致不是临时错误，不应重试，也不应进入降级模式
+	staticID := int64(5)
+	scripts := &scriptCounter{counts: map[string]int{}}
+	for _, option := range []Option{WithWorkerBits(4), WithPoolMode(PoolModeLazy)} {
+		_, err := NewRedisGenerator(client, "test-cluster", option, WithMetrics(scripts),
+			WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+			WithDegradedMode(DegradedMode{StaticWorkerID: &staticID}))
+		if !errors.Is(err, ErrPoolMismatch) {
+			t.Errorf("配置与池不一致时应该返回 ErrPoolMismatch, 实际值: %v", err)
+		}
+	}
+	if n := scripts.counts["init"]; n != 2 {
+		t.Errorf("配置与池不一致时不应重试, 初始化脚本执行次数: %d", n)
+	}
+	if IsRetryable(fmt.Errorf("wrapped: %w", ErrPoolMismatch)) {
+		t.Error("ErrPoolMismatch 不应可重试")
+	}
+	if kind := ErrorKind(ErrPoolMismatch); kind != "pool_mismatch" {
+		t.Errorf("ErrPoolMismatch 的分类应该为 pool_mismatch, 实际值: %s", kind)
+	}
+}
+
+func TestRedisGenerator_DegradedInitDoesNotBlock(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	mr.SetError(loadingError)
+	staticID := int64(1)
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: 200 * time.Millisecond}),
+		WithDegradedMode(DegradedMode{StaticWorkerID: &staticID, FailureThreshold: 100}))
+	if err != nil {
+		t.Fatalf("降级模式下创建 RedisGenerator 失败: %v", err)
+	}
+
+	// 补充初始化在重试等待期间不应阻塞其他操作
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		gen.GetID()
+	}()
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	gen.IsUnverified(staticID)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("补充初始化期间 IsUnverified 被阻塞了 %v", elapsed)
+	}
+	<-done
+}
+
+func TestRedisGenerator_DegradedCacheFile(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	mode := DegradedMode{CacheFile: filepath.Join(t.TempDir(), "lease.json")}
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithDegradedMode(mode))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	first, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if err := gen.Renew(first, token); err != nil {
+		t.Fatalf("Renew() 失败: %v", err)
+	}
+	record, err := readLeaseRecord(mode.CacheFile)
+	if err != nil || record == nil {
+		t.Fatalf("GetID 后应该写入租约文件: %v", err)
+	}
+	if record.WorkerID != first || record.Token != token || record.Pool != gen.getIDsKey() {
+		t.Errorf("租约文件内容不正确: %+v", record)
+	}
+
+	// Redis 不可用时使用租约文件中的 WorkerID
+	mr.SetError(loadingError)
+	restarted, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithDegradedMode(mode))
+	if err != nil {
+		t.Fatalf("降级模式下创建 RedisGenerator 失败: %v", err)
+	}
+	workerID, unverified, err := restarted.GetID()
+	if err != nil {
+		t.Fatalf("降级模式下 GetID() 失败: %v", err)
+	}
+	if workerID != first || !restarted.IsUnverified(workerID) {
+		t.Fatalf("应该返回租约文件中未确认的 WorkerID %d, 实际值: %d", first, workerID)
+	}
+
+	// 未确认的租约释放时只在本地丢弃
+	if err := restarted.Release(workerID, unverified); err != nil {
+		t.Errorf("释放未确认的租约失败: %v", err)
+	}
+	if restarted.IsUnverified(workerID) {
+		t.Error("释放后不应再是未确认的租约")
+	}
+
+	// 其他集群的租约文件不会被使用
+	other, err := NewRedisGenerator(client, "other-cluster", WithWorkerBits(2), WithDegradedMode(mode))
+	if err != nil {
+		t.Fatalf("降级模式下创建 RedisGenerator 失败: %v", err)
+	}
+	if _, _, err := other.GetID(); err == nil {
+		t.Error("租约文件属于其他集群且未配置静态 WorkerID 时 GetID 应该返回错误")
+	}
+}
+
+func TestRedisGenerator_ReconcileConflict(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	staticID := int64(0)
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2),
+		WithDegradedMode(DegradedMode{StaticWorkerID: &staticID}))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	mr.SetError(loadingError)
+	workerID, token, err := gen.GetID()
+	if err != nil || !gen.IsUnverified(workerID) {
+		t.Fatalf("降级模式下应该返回未确认的 WorkerID: %v", err)
+	}
+
+	// 降级期间该 ID 被其他实例分配
+	mr.SetError("")
+	peer, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	if id, _, err := peer.GetID(); err != nil || id != workerID {
+		t.Fatalf("其他实例应该分配到 WorkerID %d, 实际值: %d, 错误: %v", workerID, id, err)
+	}
+
+	if err := gen.Reconcile(context.Background()); !errors.Is(err, ErrWorkerIDInUse) {
+		t.Errorf("ID 已被占用时 Reconcile 应该返回 ErrWorkerIDInUse, 实际值: %v", err)
+	}
+	if gen.IsUnverified(workerID) {
+		t.Error("被拒绝后不应再是未确认的租约")
+	}
+	if err := gen.Renew(workerID, token); !errors.Is(err, ErrTokenMismatch) {
+		t.Errorf("被拒绝的租约 Renew 应该返回 ErrTokenMismatch, 实际值: %v", err)
+	}
+}
+
+func TestRedisGenerator_CircuitBreaker(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2),
+		WithDegradedMode(DegradedMode{FailureThreshold: 2, Cooldown: 100 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+
+	mr.SetError(loadingError)
+	for i := 0; i < 2; i++ {
+		if err := gen.Renew(workerID, token); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("第 %d 次失败应该返回 Redis 的错误, 实际值: %v", i+1, err)
+		}
+	}
+	if err := gen.Renew(workerID, token); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("连续失败达到阈值后应该返回 ErrCircuitOpen, 实际值: %v", err)
+	}
+	if kind := ErrorKind(ErrCircuitOpen); kind != "circuit_open" {
+		t.Errorf("ErrCircuitOpen 的分类应该为 circuit_open, 实际值: %s", kind)
+	}
+
+	// 未配置缓存和静态 WorkerID 时 GetID 直接返回错误
+	if _, _, err := gen.GetID(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("断路器打开时 GetID 应该返回 ErrCircuitOpen, 实际值: %v", err)
+	}
+
+	// 冷却结束后再次访问 Redis
+	mr.SetError("")
+	time.Sleep(150 * time.Millisecond)
+	if err := gen.Renew(workerID, token); err != nil {
+		t.Errorf("冷却结束且 Redis 恢复后 Renew 应该成功: %v", err)
+	}
+}
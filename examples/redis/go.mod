@@ -7,4 +7,9 @@ require (
 	libx.net/workerid v0.0.0
 )
 
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+)
+
 replace libx.net/workerid => ../../
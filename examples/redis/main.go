@@ -2,11 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os"
-	"os/signal"
-	"syscall"
+	"net/http"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -30,55 +29,60 @@ func main() {
 	// 创建RedisGenerator
 	generator, err := workerid.NewRedisGenerator(
 		client,
-		"my-app-cluster",             // 集群名称
-		workerid.WithMaxWorkerID(50), // 最大50个worker
-		workerid.WithMaxLeaseTime(2*time.Minute), // 2分钟租约
+		"my-app-cluster",           // 集群名称
+		workerid.WithWorkerBits(6), // 最多64个worker
+		workerid.WithMaxLeaseTime(2*time.Minute),                // 2分钟租约
+		workerid.WithRetryPolicy(workerid.DefaultRetryPolicy()), // 临时错误自动重试
 	)
 	if err != nil {
 		log.Fatalf("Failed to create RedisGenerator: %v", err)
 	}
 
-	// 获取worker ID
-	workerID, token, err := generator.GetID()
+	// 获取worker ID并在后台续约，收到SIGINT/SIGTERM或租约丢失时自动释放
+	session, err := workerid.NewSession(ctx, generator, workerid.SessionOptions{})
 	if err != nil {
 		log.Fatalf("Failed to get worker ID: %v", err)
 	}
 
-	fmt.Printf("✅ Acquired worker ID: %d\n", workerID)
-	fmt.Printf("🔑 Token: %s\n", token)
+	fmt.Printf("✅ Acquired worker ID: %d\n", session.WorkerID())
+	fmt.Printf("🔑 Token: %s\n", session.Token())
 
-	// 设置信号处理，优雅退出
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	// 创建续约定时器
-	renewTicker := time.NewTicker(30 * time.Second) // 每30秒续约一次
-	defer renewTicker.Stop()
-
-	// 主循环
-	running := true
-	for running {
-		select {
-		case <-sigChan:
-			fmt.Println("\n🛑 Received shutdown signal")
-			running = false
+	// 使用worker ID的请求通过session.Do执行，关闭时等待进行中的请求结束后再释放worker ID
+	mux := http.NewServeMux()
+	mux.HandleFunc("/id", func(w http.ResponseWriter, r *http.Request) {
+		err := session.Do(func(workerID int64) error {
+			_, err := fmt.Fprintf(w, "worker ID: %d\n", workerID)
+			return err
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		}
+	})
+	srv := &http.Server{Addr: ":8080", Handler: mux}
+	session.RegisterOnShutdown(srv)
 
-		case <-renewTicker.C:
-			// 续约worker ID
-			if err := generator.Renew(workerID, token); err != nil {
-				log.Printf("⚠️ Failed to renew worker ID: %v", err)
-				running = false
-			} else {
-				fmt.Printf("🔄 Worker ID %d renewed successfully\n", workerID)
-			}
+	// 收到信号或租约丢失时关闭HTTP服务
+	go func() {
+		<-session.Context().Done()
+		fmt.Println("\n🛑 Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("⚠️ Failed to shut down HTTP server: %v", err)
 		}
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("⚠️ HTTP server failed: %v", err)
+		session.Shutdown(context.Background())
 	}
 
-	// 释放worker ID
-	if err := generator.Release(workerID, token); err != nil {
-		log.Printf("⚠️ Failed to release worker ID: %v", err)
+	// 等待worker ID释放
+	<-session.Done()
+	if err := session.Err(); err != nil {
+		log.Printf("⚠️ Worker ID lease lost: %v", err)
 	} else {
-		fmt.Printf("✅ Worker ID %d released successfully\n", workerID)
+		fmt.Printf("✅ Worker ID %d released\n", session.WorkerID())
 	}
 
 	fmt.Println("👋 Application exited")
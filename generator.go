@@ -1,9 +1,11 @@
 package workerid
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"os"
 )
 
 type Generator interface {
@@ -15,6 +17,14 @@ type Generator interface {
 	Release(workerID int64, token string) error
 }
 
+// ContextGenerator 支持传入 context 的 Generator，context 用于超时控制和链路追踪
+type ContextGenerator interface {
+	Generator
+	GetIDContext(ctx context.Context) (int64, string, error)
+	RenewContext(ctx context.Context, workerID int64, token string) error
+	ReleaseContext(ctx context.Context, workerID int64, token string) error
+}
+
 var (
 	ErrNoAvailableID   = errors.New("no available worker IDs")
 	ErrInvalidWorkerID = errors.New("invalid worker ID")
@@ -22,6 +32,10 @@ var (
 	ErrTokenExpired    = errors.New("token expired")
 	ErrNotAssigned     = errors.New("worker ID not assigned")
 	ErrInvalidToken    = errors.New("invalid token format")
+	ErrWorkerIDInUse   = errors.New("worker ID in use")
+	ErrCircuitOpen     = errors.New("circuit breaker open")
+	ErrNotReserved     = errors.New("worker ID not reserved")
+	ErrPoolMismatch    = errors.New("pool configuration mismatch")
 )
 
 func generateToken() string {
@@ -32,3 +46,22 @@ func generateToken() string {
 	}
 	return base64.URLEncoding.WithPadding(base64.NoPadding).EncodeToString(tokenBytes)
 }
+
+// Holder WorkerID 持有者的元数据，随租约一起存储，便于排查是哪个实例持有某个 ID
+type Holder struct {
+	Hostname string            `json:"hostname,omitempty"`
+	Pod      string            `json:"pod,omitempty"`
+	PID      int               `json:"pid,omitempty"`
+	Version  string            `json:"version,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+// LocalHolder 返回当前进程的持有者信息，Pod 名称取自 POD_NAME 环境变量
+func LocalHolder() Holder {
+	hostname, _ := os.Hostname()
+	return Holder{
+		Hostname: hostname,
+		Pod:      os.Getenv("POD_NAME"),
+		PID:      os.Getpid(),
+	}
+}
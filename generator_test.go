@@ -122,6 +122,10 @@ func TestGenerator_ErrorTypes(t *testing.T) {
 		ErrTokenExpired,
 		ErrNotAssigned,
 		ErrInvalidToken,
+		ErrWorkerIDInUse,
+		ErrCircuitOpen,
+		ErrNotReserved,
+		ErrPoolMismatch,
 	}
 
 	for _, err := range errors {
@@ -131,6 +135,9 @@ func TestGenerator_ErrorTypes(t *testing.T) {
 		if err.Error() == "" {
 			t.Errorf("错误 %T 应该有非空的错误消息", err)
 		}
+		if kind := ErrorKind(err); kind == "error" {
+			t.Errorf("预定义错误 %v 应该有对应的错误类型", err)
+		}
 	}
 }
 
@@ -0,0 +1,123 @@
+package workerid
+
+import (
+	"time"
+)
+
+// EventType 租约生命周期事件类型
+type EventType string
+
+const (
+	EventAcquired    EventType = "acquired"
+	EventRenewed     EventType = "renewed"
+	EventRenewFailed EventType = "renew_failed"
+	EventLost        EventType = "lost"
+	EventReleased    EventType = "released"
+	// EventRevoked 和 EventExpired 仅出现在 RedisGenerator.Watch 返回的池变更事件中
+	EventRevoked EventType = "revoked"
+	EventExpired EventType = "expired"
+)
+
+// LeaseEvent 租约生命周期事件
+type LeaseEvent struct {
+	Type     EventType
+	Cluster  string
+	WorkerID int64
+	Token    string
+	// Err 导致续期失败或租约丢失的错误
+	Err  error
+	Time time.Time
+}
+
+// CapacityEvent 池容量预警事件
+type CapacityEvent struct {
+	Cluster string
+	// Leased 租约有效的 ID 数量
+	Leased int64
+	// Quarantined 租约已结束但仍处于重用延迟隔离期的 ID 数量
+	Quarantined int64
+	// Total 池中 ID 的总数
+	Total     int64
+	Threshold float64
+	Time      time.Time
+}
+
+// Hooks 租约生命周期回调，未设置的回调会被忽略
+type Hooks struct {
+	// OnAcquired 成功获取 WorkerID 后调用
+	OnAcquired func(LeaseEvent)
+	// OnRenewed 成功续期后调用
+	OnRenewed func(LeaseEvent)
+	// OnRenewFailed 续期因网络等临时错误失败时调用，租约可能仍然有效
+	OnRenewFailed func(LeaseEvent)
+	// OnLost 续期发现租约已过期、被回收或被他人持有时调用，降级期间发放的 ID 确认时已被其他实例占用也会调用，
+	// 此时应停止使用该 WorkerID
+	OnLost func(LeaseEvent)
+	// OnReleased 成功释放 WorkerID 后调用
+	OnReleased func(LeaseEvent)
+	// OnLowCapacity 已分配和处于隔离期的 ID 占比达到 WithCapacityWarning 设置的阈值时调用
+	OnLowCapacity func(CapacityEvent)
+	// Async 为 true 时在独立的 goroutine 中调用回调，否则在操作返回前同步调用
+	Async bool
+}
+
+func (h *Hooks) emit(event LeaseEvent) {
+	if h == nil {
+		return
+	}
+	var fn func(LeaseEvent)
+	switch event.Type {
+	case EventAcquired:
+		fn = h.OnAcquired
+	case EventRenewed:
+		fn = h.OnRenewed
+	case EventRenewFailed:
+		fn = h.OnRenewFailed
+	case EventLost:
+		fn = h.OnLost
+	case EventReleased:
+		fn = h.OnReleased
+	}
+	if fn == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if h.Async {
+		go fn(event)
+		return
+	}
+	fn(event)
+}
+
+func (h *Hooks) emitCapacity(event CapacityEvent) {
+	if h == nil || h.OnLowCapacity == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if h.Async {
+		go h.OnLowCapacity(event)
+		return
+	}
+	h.OnLowCapacity(event)
+}
+
+// operationEvent 返回操作结果对应的事件类型，没有对应事件时返回空字符串
+func operationEvent(op Operation, err error) EventType {
+	switch {
+	case err == nil && op == OpGetID:
+		return EventAcquired
+	case err == nil && op == OpRenew:
+		return EventRenewed
+	case err == nil && op == OpRelease:
+		return EventReleased
+	case op == OpRenew && isLeaseLost(err):
+		return EventLost
+	case op == OpRenew:
+		return EventRenewFailed
+	}
+	return ""
+}
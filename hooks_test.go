@@ -0,0 +1,157 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRedisGenerator_Hooks(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	var events []LeaseEvent
+	record := func(e LeaseEvent) { events = append(events, e) }
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithHooks(Hooks{
+		OnAcquired:    record,
+		OnRenewed:     record,
+		OnRenewFailed: record,
+		OnLost:        record,
+		OnReleased:    record,
+	}))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if err := gen.Renew(workerID, token); err != nil {
+		t.Fatalf("Renew() 失败: %v", err)
+	}
+
+	// Redis 不可用时触发 renew_failed
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+	if err := gen.Renew(workerID, token); err == nil {
+		t.Fatal("Redis 不可用时 Renew() 应该返回错误")
+	}
+	mr.SetError("")
+
+	if err := gen.Release(workerID, token); err != nil {
+		t.Fatalf("Release() 失败: %v", err)
+	}
+
+	// 被回收后续期触发 lost
+	workerID2, token2, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if err := gen.Revoke(context.Background(), workerID2, "alice", "test"); err != nil {
+		t.Fatalf("Revoke() 失败: %v", err)
+	}
+	if err := gen.Renew(workerID2, token2); !errors.Is(err, ErrNotAssigned) {
+		t.Fatalf("Renew() 应该返回 ErrNotAssigned, 实际值: %v", err)
+	}
+
+	want := []EventType{EventAcquired, EventRenewed, EventRenewFailed, EventReleased, EventAcquired, EventLost}
+	if len(events) != len(want) {
+		t.Fatalf("事件数量应该为 %d, 实际值: %d", len(want), len(events))
+	}
+	for i, e := range events {
+		if e.Type != want[i] {
+			t.Errorf("第 %d 个事件应该为 %s, 实际值: %s", i, want[i], e.Type)
+		}
+		if e.Cluster != "test-cluster" || e.Time.IsZero() {
+			t.Errorf("第 %d 个事件信息不完整: %+v", i, e)
+		}
+	}
+	if events[0].WorkerID != workerID || events[0].Token != token {
+		t.Errorf("acquired 事件的 WorkerID 或 Token 不正确: %+v", events[0])
+	}
+	if events[2].Err == nil {
+		t.Error("renew_failed 事件应该包含错误")
+	}
+	if !errors.Is(events[5].Err, ErrNotAssigned) {
+		t.Errorf("lost 事件的错误应该为 ErrNotAssigned, 实际值: %v", events[5].Err)
+	}
+}
+
+func TestHooks_Async(t *testing.T) {
+	done := make(chan LeaseEvent, 1)
+	hooks := &Hooks{
+		OnLost: func(e LeaseEvent) {
+			done <- e
+		},
+		Async: true,
+	}
+
+	hooks.emit(LeaseEvent{Type: EventLost, WorkerID: 3, Err: ErrTokenExpired})
+	// 未设置的回调应该被忽略
+	hooks.emit(LeaseEvent{Type: EventRenewed, WorkerID: 3})
+
+	select {
+	case e := <-done:
+		if e.WorkerID != 3 || !errors.Is(e.Err, ErrTokenExpired) {
+			t.Errorf("事件信息不正确: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("异步回调未被调用")
+	}
+
+	// nil Hooks 不应该 panic
+	var nilHooks *Hooks
+	nilHooks.emit(LeaseEvent{Type: EventAcquired})
+}
+
+func TestRedisGenerator_CapacityWarning(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	var events []CapacityEvent
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithCapacityWarning(0.75),
+		WithHooks(Hooks{OnLowCapacity: func(e CapacityEvent) { events = append(events, e) }}))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	// 4 个 ID 中分配 2 个，未达到阈值
+	for i := 0; i < 2; i++ {
+		if _, _, err := gen.GetID(); err != nil {
+			t.Fatalf("GetID() 失败: %v", err)
+		}
+	}
+	if len(events) != 0 {
+		t.Fatalf("未达到阈值时不应该触发预警, 实际触发 %d 次", len(events))
+	}
+
+	// 第 3 个 ID 达到 75%
+	if _, _, err := gen.GetID(); err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("达到阈值时应该触发 1 次预警, 实际触发 %d 次", len(events))
+	}
+	if e := events[0]; e.Leased != 3 || e.Total != 4 || e.Threshold != 0.75 || e.Cluster != "test-cluster" {
+		t.Errorf("预警事件信息不正确: %+v", e)
+	}
+
+	// 池耗尽时也会触发预警
+	if _, _, err := gen.GetID(); err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if _, _, err := gen.GetID(); !errors.Is(err, ErrNoAvailableID) {
+		t.Fatalf("池耗尽时 GetID() 应该返回 ErrNoAvailableID, 实际值: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("应该触发 3 次预警, 实际触发 %d 次", len(events))
+	}
+	if e := events[2]; e.Leased != 4 || e.Total != 4 {
+		t.Errorf("池耗尽时的预警事件信息不正确: %+v", e)
+	}
+}
@@ -0,0 +1,120 @@
+package workerid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// leaseRecord 保存在本地文件中的租约
+type leaseRecord struct {
+	// Pool 租约所属的池，包含键前缀、集群和数据中心，避免不同的池共用同一个文件时误用
+	Pool     string `json:"pool"`
+	WorkerID int64  `json:"worker_id"`
+	Token    string `json:"token"`
+	ExpireAt int64  `json:"expire_at"`
+}
+
+// readLeaseRecord 读取本地租约文件，文件不存在时返回 nil
+func readLeaseRecord(path string) (*leaseRecord, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	record := &leaseRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, fmt.Errorf("decode lease file failed: %w", err)
+	}
+	return record, nil
+}
+
+// writeLeaseRecord 先写入临时文件再重命名，避免进程崩溃时留下不完整的文件
+func writeLeaseRecord(path string, record leaseRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// leaseFiles 返回需要记录租约的本地文件：WithLeaseFile 设置的文件和降级模式的缓存文件
+func (g *RedisGenerator) leaseFiles() []string {
+	var paths []string
+	if g.leaseFile != "" {
+		paths = append(paths, g.leaseFile)
+	}
+	if g.degraded != nil && g.degraded.CacheFile != "" && g.degraded.CacheFile != g.leaseFile {
+		paths = append(paths, g.degraded.CacheFile)
+	}
+	return paths
+}
+
+// saveLease 记录最近一次分配或续期成功的租约，写入失败只记录日志
+func (g *RedisGenerator) saveLease(ctx context.Context, workerID int64, token string, expireAt int64) {
+	record := leaseRecord{Pool: g.getIDsKey(), WorkerID: workerID, Token: token, ExpireAt: expireAt}
+	for _, path := range g.leaseFiles() {
+		if err := writeLeaseRecord(path, record); err != nil {
+			g.logger.WarnContext(ctx, "save lease file failed", slog.String("path", path), slog.Any("error", err))
+		}
+	}
+}
+
+// removeLease 释放成功后删除 WithLeaseFile 设置的文件中的租约，使重启后不再尝试恢复。
+// 降级模式的缓存文件保留，Redis 不可用时仍可使用其中的 WorkerID
+func (g *RedisGenerator) removeLease(ctx context.Context, workerID int64, token string) {
+	if g.leaseFile == "" {
+		return
+	}
+	record, err := readLeaseRecord(g.leaseFile)
+	if err != nil || record == nil || record.WorkerID != workerID || record.Token != token {
+		return
+	}
+	if err := os.Remove(g.leaseFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		g.logger.WarnContext(ctx, "remove lease file failed", slog.String("path", g.leaseFile), slog.Any("error", err))
+	}
+}
+
+// resumeLease 使用 WithLeaseFile 记录的租约续期，成功时继续使用上一次的 WorkerID 和 Token。
+// 租约已过期、已被回收或 Redis 不可用时返回 false，由调用方重新分配
+func (g *RedisGenerator) resumeLease(ctx context.Context) (int64, string, bool) {
+	record, err := readLeaseRecord(g.leaseFile)
+	if err != nil {
+		g.logger.WarnContext(ctx, "read lease file failed", slog.String("path", g.leaseFile), slog.Any("error", err))
+		return 0, "", false
+	}
+	if record == nil || record.Pool != g.getIDsKey() || record.ExpireAt <= time.Now().Unix() {
+		return 0, "", false
+	}
+	id, err := g.machineID(record.WorkerID)
+	if err != nil || len(record.Token) != 22 {
+		return 0, "", false
+	}
+
+	if err := g.renew(ctx, id, record.WorkerID, record.Token); err != nil {
+		g.logger.InfoContext(ctx, "resume lease failed", slog.Int64("worker_id", record.WorkerID),
+			slog.Any("error", err))
+		return 0, "", false
+	}
+	g.logger.InfoContext(ctx, "lease resumed", slog.Int64("worker_id", record.WorkerID))
+	return record.WorkerID, record.Token, true
+}
@@ -0,0 +1,104 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRedisGenerator_WithLeaseFile(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	path := filepath.Join(t.TempDir(), "lease.json")
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithLeaseFile(path))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+
+	// 模拟进程重启：新实例的第一次 GetID 恢复上一次的租约
+	restarted, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithLeaseFile(path))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	resumedID, resumedToken, err := restarted.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if resumedID != workerID || resumedToken != token {
+		t.Fatalf("重启后应该恢复 WorkerID %d, 实际值: %d, Token 相同: %v", workerID, resumedID, resumedToken == token)
+	}
+	stats, err := restarted.Stats(context.Background())
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if stats.Leased != 1 {
+		t.Errorf("恢复租约不应分配新的 ID, 已分配数量: %d", stats.Leased)
+	}
+
+	// 之后的 GetID 照常分配新的 ID
+	nextID, nextToken, err := restarted.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if nextID == workerID {
+		t.Errorf("第二次 GetID 不应返回已恢复的 WorkerID %d", workerID)
+	}
+
+	// 租约文件只记录最近一次的租约，释放其他租约不影响该文件
+	if err := restarted.Release(resumedID, resumedToken); err != nil {
+		t.Fatalf("Release() 失败: %v", err)
+	}
+	if record, err := readLeaseRecord(path); err != nil || record == nil || record.WorkerID != nextID {
+		t.Fatalf("租约文件应该记录 WorkerID %d: %+v, 错误: %v", nextID, record, err)
+	}
+
+	// 释放后删除租约文件，重启后不再恢复
+	if err := restarted.Release(nextID, nextToken); err != nil {
+		t.Fatalf("Release() 失败: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("释放后应该删除租约文件, 实际错误: %v", err)
+	}
+}
+
+func TestRedisGenerator_WithLeaseFileRevoked(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	path := filepath.Join(t.TempDir(), "lease.json")
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithLeaseFile(path))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if err := gen.Revoke(context.Background(), workerID, "admin", "test"); err != nil {
+		t.Fatalf("Revoke() 失败: %v", err)
+	}
+
+	// 租约已被回收时重新分配
+	restarted, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithLeaseFile(path))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	_, newToken, err := restarted.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if newToken == token {
+		t.Error("租约已被回收时不应恢复旧的 Token")
+	}
+	record, err := readLeaseRecord(path)
+	if err != nil || record == nil || record.Token != newToken {
+		t.Errorf("租约文件应该记录新分配的租约: %+v, 错误: %v", record, err)
+	}
+}
@@ -0,0 +1,33 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// discardHandler 丢弃所有日志，未设置 WithLogger 时使用
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// newLogger 返回带有集群属性的 logger，logger 为 nil 时丢弃所有日志
+func newLogger(logger *slog.Logger, cluster string) *slog.Logger {
+	if logger == nil {
+		return slog.New(discardHandler{})
+	}
+	if cluster == "" {
+		return logger
+	}
+	return logger.With(slog.String("cluster", cluster))
+}
+
+// isLeaseLost 判断错误是否表示租约已经丢失，此时继续续期没有意义。
+// 降级期间发放的 ID 在确认时已被其他实例占用（ErrWorkerIDInUse）同样视为丢失
+func isLeaseLost(err error) bool {
+	return errors.Is(err, ErrTokenExpired) || errors.Is(err, ErrNotAssigned) || errors.Is(err, ErrTokenMismatch) ||
+		errors.Is(err, ErrWorkerIDInUse)
+}
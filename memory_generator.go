@@ -1,6 +1,8 @@
 package workerid
 
 import (
+	"context"
+	"log/slog"
 	"math/rand/v2"
 )
 
@@ -8,8 +10,11 @@ import (
 type MemoryGenerator struct {
 	workerID int64
 	token    string
+	logger   *slog.Logger
 }
 
+var _ ContextGenerator = (*MemoryGenerator)(nil)
+
 func NewMemoryGenerator(options ...Option) *MemoryGenerator {
 	opts := &generatorOptions{
 		maxWorkerID: 511,
@@ -22,11 +27,27 @@ func NewMemoryGenerator(options ...Option) *MemoryGenerator {
 		maxId = 511
 	}
 	randomUint32 := uint32(rand.N(uint64(maxId))) + 1
+	// 从随机位置开始向后查找第一个未预留的 ID
+	for i := uint32(0); i < maxId; i++ {
+		id := (randomUint32+i-1)%maxId + 1
+		if _, ok := opts.reserved[id]; !ok {
+			randomUint32 = id
+			break
+		}
+	}
 
-	return &MemoryGenerator{
-		workerID: int64(randomUint32),
+	workerID := int64(randomUint32)
+	if opts.datacenterBits > 0 {
+		workerID |= int64(opts.datacenterID) << opts.machineBits
+	}
+
+	g := &MemoryGenerator{
+		workerID: workerID,
 		token:    generateToken(),
+		logger:   newLogger(opts.logger, ""),
 	}
+	g.logger.Info("worker ID assigned", slog.Int64("worker_id", g.workerID))
+	return g
 }
 
 func (g *MemoryGenerator) GetID() (int64, string, error) {
@@ -50,5 +71,21 @@ func (g *MemoryGenerator) Release(workerID int64, token string) error {
 	if token != g.token {
 		return ErrTokenMismatch
 	}
+	g.logger.Info("worker ID released", slog.Int64("worker_id", workerID))
 	return nil // 单机环境无需释放
 }
+
+// GetIDContext 同 GetID，单机环境无需使用 context
+func (g *MemoryGenerator) GetIDContext(context.Context) (int64, string, error) {
+	return g.GetID()
+}
+
+// RenewContext 同 Renew，单机环境无需使用 context
+func (g *MemoryGenerator) RenewContext(_ context.Context, workerID int64, token string) error {
+	return g.Renew(workerID, token)
+}
+
+// ReleaseContext 同 Release，单机环境无需使用 context
+func (g *MemoryGenerator) ReleaseContext(_ context.Context, workerID int64, token string) error {
+	return g.Release(workerID, token)
+}
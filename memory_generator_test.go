@@ -163,3 +163,20 @@ func TestMemoryGenerator_MultipleInstances(t *testing.T) {
 		t.Error("不同实例的 Token 不应该相同")
 	}
 }
+
+func TestMemoryGenerator_Reserved(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		gen := NewMemoryGenerator(WithWorkerBits(2), WithReservedRange(0, 2))
+		if workerID, _, _ := gen.GetID(); workerID != 3 {
+			t.Fatalf("只有 WorkerID 3 未被预留, 实际值: %d", workerID)
+		}
+	}
+}
+
+func TestMemoryGenerator_WithDatacenter(t *testing.T) {
+	gen := NewMemoryGenerator(WithDatacenter(3, 3, 7))
+	workerID, _, _ := gen.GetID()
+	if workerID>>7 != 3 {
+		t.Errorf("WorkerID %d 的数据中心部分应该为 3", workerID)
+	}
+}
@@ -0,0 +1,63 @@
+package workerid
+
+import (
+	"errors"
+	"time"
+)
+
+// Operation Generator 的操作类型
+type Operation string
+
+const (
+	OpGetID   Operation = "get_id"
+	OpRenew   Operation = "renew"
+	OpRelease Operation = "release"
+)
+
+// Metrics 指标采集接口，实现需要是并发安全的。
+// 参数 cluster 为集群名称，使用 WithKeyPrefix 或 Factory 时带有键前缀，如 "platform:acme:orders"。
+// Prometheus 的实现见 libx.net/workerid/prommetrics 包。
+type Metrics interface {
+	// OperationCompleted 记录一次操作的结果，err 为 nil 表示成功
+	OperationCompleted(cluster string, op Operation, err error)
+	// ScriptExecuted 记录一次 Redis 脚本的执行耗时
+	ScriptExecuted(cluster, script string, duration time.Duration)
+	// PoolUsage 记录池中已分配和空闲 ID 的数量
+	PoolUsage(cluster string, leased, free int64)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) OperationCompleted(string, Operation, error)  {}
+func (nopMetrics) ScriptExecuted(string, string, time.Duration) {}
+func (nopMetrics) PoolUsage(string, int64, int64)               {}
+
+// ErrorKind 返回错误的分类名称，用作指标和追踪的标签值，err 为 nil 时返回 "ok"
+func ErrorKind(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrNoAvailableID):
+		return "no_available_id"
+	case errors.Is(err, ErrInvalidWorkerID):
+		return "invalid_worker_id"
+	case errors.Is(err, ErrTokenMismatch):
+		return "token_mismatch"
+	case errors.Is(err, ErrTokenExpired):
+		return "token_expired"
+	case errors.Is(err, ErrNotAssigned):
+		return "not_assigned"
+	case errors.Is(err, ErrInvalidToken):
+		return "invalid_token"
+	case errors.Is(err, ErrWorkerIDInUse):
+		return "worker_id_in_use"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, ErrNotReserved):
+		return "not_reserved"
+	case errors.Is(err, ErrPoolMismatch):
+		return "pool_mismatch"
+	default:
+		return "error"
+	}
+}
@@ -1,20 +1,81 @@
 package workerid
 
 import (
+	"log/slog"
 	"time"
 )
 
 type generatorOptions struct {
-	cluster      string
-	maxWorkerID  uint32
-	maxLeaseTime time.Duration
+	cluster         string
+	maxWorkerID     uint32
+	maxLeaseTime    time.Duration
+	holder          *Holder
+	metrics         Metrics
+	logger          *slog.Logger
+	hooks           *Hooks
+	capacityWarning float64
+	reuseDelay      time.Duration
+	strategy        AllocationStrategy
+	reserved        map[uint32]string
+	datacenterID    uint32
+	datacenterBits  uint
+	machineBits     uint
+	keyPrefix       string
+	poolMode        PoolMode
+	retryPolicy     RetryPolicy
+	degradedMode    *DegradedMode
+	leaseFile       string
 }
 
 type Option func(*generatorOptions)
 
+// AllocationStrategy RedisGenerator 选择可用 WorkerID 的策略
+type AllocationStrategy string
+
+const (
+	// AllocateLowest 选择分数最小的可用 ID，释放的 ID 分数为 0 会被优先重用（默认）
+	AllocateLowest AllocationStrategy = "lowest"
+	// AllocateLeastRecentlyUsed 选择释放或过期最早的 ID，使重用均匀分布在整个池中。
+	// PoolModeLazy 和 PoolModeBitmap 下从未分配过的 ID 视为空闲最久，用完后才重用释放过的 ID
+	AllocateLeastRecentlyUsed AllocationStrategy = "lru"
+	// AllocateRandom 从可用 ID 中随机选择，PoolModeLazy 和 PoolModeBitmap 下包括从未分配过的 ID
+	AllocateRandom AllocationStrategy = "random"
+)
+
+// PoolMode RedisGenerator 在 Redis 中存储池的方式
+type PoolMode string
+
+const (
+	// PoolModeEager 初始化时将所有 ID 写入 Sorted Set（默认）
+	PoolModeEager PoolMode = "eager"
+	// PoolModeLazy 只存储分配过的 ID，从未分配过的 ID 由游标按顺序发放，
+	// 内存占用与同时在用的 ID 数量成正比，初始化的耗时与 ID 范围无关，适合较大的 WorkerBits
+	PoolModeLazy PoolMode = "lazy"
+	// PoolModeBitmap 使用位图记录在用的 ID，通过 BITPOS 查找最小的空闲 ID，Sorted Set 中只保存在用 ID 的过期时间，
+	// 适合 20 位以上的 ID 范围
+	PoolModeBitmap PoolMode = "bitmap"
+)
+
+// WithWorkerBits 设置 WorkerID 的位数，同时取消之前通过 WithDatacenter 设置的数据中心划分
 func WithWorkerBits(workerBits uint) Option {
 	return func(o *generatorOptions) {
 		o.maxWorkerID = 1<<workerBits - 1
+		o.machineBits = workerBits
+		o.datacenterID = 0
+		o.datacenterBits = 0
+	}
+}
+
+// WithDatacenter 将 WorkerID 划分为数据中心和机器两部分，例如 3 位数据中心 ID 和 7 位机器 ID。
+// 每个数据中心使用独立的池，池中保存机器 ID，返回的 WorkerID 已组合为 datacenterID<<machineBits | machineID，
+// 共用同一个 Redis 的数据中心之间不会冲突。与 WithWorkerBits 同时使用时以后设置的为准，
+// 之后设置 WithWorkerBits 时不再划分数据中心
+func WithDatacenter(datacenterID uint32, datacenterBits, machineBits uint) Option {
+	return func(o *generatorOptions) {
+		o.datacenterID = datacenterID
+		o.datacenterBits = datacenterBits
+		o.machineBits = machineBits
+		o.maxWorkerID = 1<<machineBits - 1
 	}
 }
 
@@ -23,3 +84,123 @@ func WithMaxLeaseTime(maxLeaseTime time.Duration) Option {
 		o.maxLeaseTime = maxLeaseTime
 	}
 }
+
+// WithHolder 设置分配 WorkerID 时默认记录的持有者信息
+func WithHolder(holder Holder) Option {
+	return func(o *generatorOptions) {
+		o.holder = &holder
+	}
+}
+
+// WithMetrics 设置指标采集器，用于记录操作结果、脚本耗时和池的使用情况
+func WithMetrics(metrics Metrics) Option {
+	return func(o *generatorOptions) {
+		o.metrics = metrics
+	}
+}
+
+// WithLogger 设置日志记录器，默认不输出任何日志
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *generatorOptions) {
+		o.logger = logger
+	}
+}
+
+// WithHooks 设置租约生命周期回调
+func WithHooks(hooks Hooks) Option {
+	return func(o *generatorOptions) {
+		o.hooks = &hooks
+	}
+}
+
+// WithCapacityWarning 设置容量预警阈值（0, 1]，例如 0.8 表示已分配的 ID 达到池大小的 80% 时，
+// 每次 GetID 都会输出告警日志并触发 Hooks.OnLowCapacity
+func WithCapacityWarning(threshold float64) Option {
+	return func(o *generatorOptions) {
+		o.capacityWarning = threshold
+	}
+}
+
+// WithReuseDelay 设置 WorkerID 在释放、回收或过期后重新分配前的隔离时间，
+// 防止短暂失联或 GC 停顿的原持有者与新持有者同时使用同一个 ID，精度为秒
+func WithReuseDelay(delay time.Duration) Option {
+	return func(o *generatorOptions) {
+		o.reuseDelay = delay
+	}
+}
+
+// WithAllocationStrategy 设置选择可用 WorkerID 的策略，默认为 AllocateLowest
+func WithAllocationStrategy(strategy AllocationStrategy) Option {
+	return func(o *generatorOptions) {
+		o.strategy = strategy
+	}
+}
+
+// WithPoolMode 设置池的存储方式，默认为 PoolModeEager。同一个池的所有实例必须使用相同的方式
+func WithPoolMode(mode PoolMode) Option {
+	return func(o *generatorOptions) {
+		o.poolMode = mode
+	}
+}
+
+// WithRetryPolicy 设置 GetID、Renew、Release 和初始化池时遇到临时错误的重试策略，默认不重试。
+// GetID 的脚本在连接中断前可能已经执行，重试会使上一次分配的 ID 直到租约到期才能被重新分配
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *generatorOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithDegradedMode 开启降级模式：连续访问 Redis 失败达到阈值后断路器打开，GetID 不再访问 Redis，
+// 而是返回本地缓存的上一次分配到的 WorkerID 或配置的静态 WorkerID，并标记为未确认（见 IsUnverified）。
+// Redis 恢复后，未确认的租约在下一次 Renew 或 Reconcile 时确认，若该 ID 已被其他实例占用则返回 ErrWorkerIDInUse，
+// 此时调用方必须停止使用该 ID。开启后 Redis 不可用时 NewRedisGenerator 也不会返回错误
+func WithDegradedMode(mode DegradedMode) Option {
+	return func(o *generatorOptions) {
+		o.degradedMode = &mode
+	}
+}
+
+// WithLeaseFile 将最近一次分配或续期成功的租约（WorkerID、Token 和过期时间）保存到本地文件 path。
+// 进程在租约有效期内重启时，第一次 GetID 先使用文件中的 Token 续期，成功则继续使用上一次的 WorkerID，
+// 避免旧租约直到过期才能被重新分配；续期失败时照常分配。Release 成功后删除该文件
+func WithLeaseFile(path string) Option {
+	return func(o *generatorOptions) {
+		o.leaseFile = path
+	}
+}
+
+// WithKeyPrefix 设置 Redis 键的前缀（默认为 "workerid"），键的格式为 {prefix:cluster:<cluster>}:ids 等。
+// 多个环境或租户共用同一个 Redis 时，使用不同的前缀隔离各自的池
+func WithKeyPrefix(prefix string) Option {
+	return func(o *generatorOptions) {
+		o.keyPrefix = prefix
+	}
+}
+
+// WithReservedRange 将 [from, to] 范围内的 WorkerID 预留给不经过 Redis 分配、手工配置的服务，
+// 预留的 ID 不会加入池中，GetID 也不会返回它们。可多次调用以预留多个范围。
+// 移除该选项不会取消 Redis 中的预留，需要调用 RedisGenerator.Unreserve 将 ID 放回池中
+func WithReservedRange(from, to uint32) Option {
+	return func(o *generatorOptions) {
+		if o.reserved == nil {
+			o.reserved = make(map[uint32]string)
+		}
+		for id := uint64(from); id <= uint64(to); id++ {
+			if _, ok := o.reserved[uint32(id)]; !ok {
+				o.reserved[uint32(id)] = ""
+			}
+		}
+	}
+}
+
+// WithStaticAssignment 将 WorkerID 固定分配给名为 name 的持有者，该 ID 同样被预留，
+// 可通过 RedisGenerator.Reservations 查询
+func WithStaticAssignment(workerID uint32, name string) Option {
+	return func(o *generatorOptions) {
+		if o.reserved == nil {
+			o.reserved = make(map[uint32]string)
+		}
+		o.reserved[workerID] = name
+	}
+}
@@ -0,0 +1,153 @@
+// Package otelworkerid 为 workerid.Generator 提供 OpenTelemetry 链路追踪
+package otelworkerid
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"libx.net/workerid"
+)
+
+const instrumentationName = "libx.net/workerid/otelworkerid"
+
+// 链路追踪使用的属性
+const (
+	ClusterKey   = attribute.Key("workerid.cluster")
+	WorkerIDKey  = attribute.Key("workerid.worker_id")
+	BackendKey   = attribute.Key("workerid.backend")
+	ErrorKindKey = attribute.Key("workerid.error_kind")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	cluster        string
+	backend        string
+}
+
+// Option 配置 Wrap 返回的 Generator
+type Option func(*config)
+
+// WithTracerProvider 设置 TracerProvider，默认使用 otel.GetTracerProvider()
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithCluster 设置 span 中的集群名称，默认取自 RedisGenerator.Cluster()
+func WithCluster(cluster string) Option {
+	return func(c *config) {
+		c.cluster = cluster
+	}
+}
+
+// WithBackend 设置 span 中的后端名称，默认根据 Generator 类型推断
+func WithBackend(backend string) Option {
+	return func(c *config) {
+		c.backend = backend
+	}
+}
+
+// Generator 在 GetID、Renew 和 Release 外层创建 span 的 Generator
+type Generator struct {
+	next   workerid.ContextGenerator
+	tracer trace.Tracer
+	attrs  []attribute.KeyValue
+}
+
+var _ workerid.ContextGenerator = (*Generator)(nil)
+
+// Wrap 包装 gen，为每次操作创建 span
+func Wrap(gen workerid.ContextGenerator, opts ...Option) *Generator {
+	cfg := &config{backend: backendOf(gen)}
+	if c, ok := gen.(interface{ Cluster() string }); ok {
+		cfg.cluster = c.Cluster()
+	}
+	for _, o := range opts {
+		o(cfg)
+	}
+	if cfg.tracerProvider == nil {
+		cfg.tracerProvider = otel.GetTracerProvider()
+	}
+
+	attrs := []attribute.KeyValue{BackendKey.String(cfg.backend)}
+	if cfg.cluster != "" {
+		attrs = append(attrs, ClusterKey.String(cfg.cluster))
+	}
+	return &Generator{
+		next:   gen,
+		tracer: cfg.tracerProvider.Tracer(instrumentationName),
+		attrs:  attrs,
+	}
+}
+
+func (g *Generator) GetID() (int64, string, error) {
+	return g.GetIDContext(context.Background())
+}
+
+func (g *Generator) Renew(workerID int64, token string) error {
+	return g.RenewContext(context.Background(), workerID, token)
+}
+
+func (g *Generator) Release(workerID int64, token string) error {
+	return g.ReleaseContext(context.Background(), workerID, token)
+}
+
+func (g *Generator) GetIDContext(ctx context.Context) (int64, string, error) {
+	ctx, span := g.start(ctx, "workerid.GetID")
+	defer span.End()
+
+	workerID, token, err := g.next.GetIDContext(ctx)
+	if err == nil {
+		span.SetAttributes(WorkerIDKey.Int64(workerID))
+	}
+	finish(span, err)
+	return workerID, token, err
+}
+
+func (g *Generator) RenewContext(ctx context.Context, workerID int64, token string) error {
+	ctx, span := g.start(ctx, "workerid.Renew", WorkerIDKey.Int64(workerID))
+	defer span.End()
+
+	err := g.next.RenewContext(ctx, workerID, token)
+	finish(span, err)
+	return err
+}
+
+func (g *Generator) ReleaseContext(ctx context.Context, workerID int64, token string) error {
+	ctx, span := g.start(ctx, "workerid.Release", WorkerIDKey.Int64(workerID))
+	defer span.End()
+
+	err := g.next.ReleaseContext(ctx, workerID, token)
+	finish(span, err)
+	return err
+}
+
+func (g *Generator) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return g.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(g.attrs...),
+		trace.WithAttributes(attrs...))
+}
+
+func finish(span trace.Span, err error) {
+	span.SetAttributes(ErrorKindKey.String(workerid.ErrorKind(err)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+func backendOf(gen workerid.ContextGenerator) string {
+	switch gen.(type) {
+	case *workerid.RedisGenerator:
+		return "redis"
+	case *workerid.MemoryGenerator:
+		return "memory"
+	default:
+		return "custom"
+	}
+}
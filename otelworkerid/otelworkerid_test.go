@@ -0,0 +1,90 @@
+package otelworkerid
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"libx.net/workerid"
+)
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestGenerator(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	redisGen, err := workerid.NewRedisGenerator(client, "otel-cluster", workerid.WithWorkerBits(2))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	gen := Wrap(redisGen, WithTracerProvider(tp))
+
+	// 父 span 应该通过 context 传递
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	workerID, token, err := gen.GetIDContext(ctx)
+	if err != nil {
+		t.Fatalf("GetIDContext() 失败: %v", err)
+	}
+	parent.End()
+	if err := gen.Renew(workerID, "abcdefghijklmnopqrstuv"); err == nil {
+		t.Fatal("Renew() 使用错误的 Token 应该返回错误")
+	}
+	if err := gen.Release(workerID, token); err != nil {
+		t.Fatalf("Release() 失败: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("span 数量应该为 4, 实际值: %d", len(spans))
+	}
+
+	getID := spans[0]
+	if getID.Name() != "workerid.GetID" {
+		t.Errorf("span 名称应该为 workerid.GetID, 实际值: %s", getID.Name())
+	}
+	if getID.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("GetID span 应该是调用方 span 的子 span")
+	}
+	if v, _ := spanAttr(getID, ClusterKey); v.AsString() != "otel-cluster" {
+		t.Errorf("cluster 属性应该为 otel-cluster, 实际值: %s", v.AsString())
+	}
+	if v, _ := spanAttr(getID, BackendKey); v.AsString() != "redis" {
+		t.Errorf("backend 属性应该为 redis, 实际值: %s", v.AsString())
+	}
+	if v, ok := spanAttr(getID, WorkerIDKey); !ok || v.AsInt64() != workerID {
+		t.Errorf("worker_id 属性应该为 %d, 实际值: %d", workerID, v.AsInt64())
+	}
+
+	renew := spans[2]
+	if renew.Status().Code != codes.Error {
+		t.Errorf("失败的 Renew span 状态应该为 Error, 实际值: %v", renew.Status().Code)
+	}
+	if v, _ := spanAttr(renew, ErrorKindKey); v.AsString() != "token_mismatch" {
+		t.Errorf("error_kind 属性应该为 token_mismatch, 实际值: %s", v.AsString())
+	}
+
+	release := spans[3]
+	if release.Status().Code == codes.Error {
+		t.Error("成功的 Release span 状态不应该为 Error")
+	}
+	if v, _ := spanAttr(release, ErrorKindKey); v.AsString() != "ok" {
+		t.Errorf("error_kind 属性应该为 ok, 实际值: %s", v.AsString())
+	}
+}
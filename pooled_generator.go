@@ -0,0 +1,197 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// standbyLease 预先获取的备用租约
+type standbyLease struct {
+	workerID int64
+	token    string
+}
+
+// PooledGenerator 在 RedisGenerator 之上预先获取 size 个备用 WorkerID 并在后台持续续期，
+// GetID 优先从本地返回备用 ID，Redis 短暂不可用时也不会阻塞。
+// 返回给调用方的 ID 不再由 PooledGenerator 续期，调用方需要像使用 RedisGenerator 一样自行续期和释放
+type PooledGenerator struct {
+	gen      *RedisGenerator
+	size     int
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	standby []standbyLease
+	closed  bool
+
+	refill chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var _ ContextGenerator = (*PooledGenerator)(nil)
+
+// NewPooledGenerator 创建 PooledGenerator 并立即获取备用 ID，获取失败时只记录日志，后台会继续重试。
+// 备用 ID 每隔租约时间的 1/3 续期一次，不再使用时必须调用 Close 释放
+func NewPooledGenerator(gen *RedisGenerator, size int) (*PooledGenerator, error) {
+	if size <= 0 {
+		return nil, errors.New("standby size must be positive")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &PooledGenerator{
+		gen:      gen,
+		size:     size,
+		interval: time.Duration(gen.leaseSeconds) * time.Second / 3,
+		logger:   gen.logger.With(slog.String("component", "pool")),
+		refill:   make(chan struct{}, 1),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	p.fill(ctx)
+	go p.run(ctx)
+	return p, nil
+}
+
+// Standby 返回当前备用 ID 的数量
+func (p *PooledGenerator) Standby() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.standby)
+}
+
+func (p *PooledGenerator) GetID() (int64, string, error) {
+	return p.GetIDContext(p.gen.ctx)
+}
+
+// GetIDContext 返回一个备用 ID 并在后台补充，没有备用 ID 时直接从 Redis 获取
+func (p *PooledGenerator) GetIDContext(ctx context.Context) (int64, string, error) {
+	p.mu.Lock()
+	if n := len(p.standby); n > 0 && !p.closed {
+		lease := p.standby[0]
+		p.standby = p.standby[1:]
+		p.mu.Unlock()
+
+		select {
+		case p.refill <- struct{}{}:
+		default:
+		}
+		return lease.workerID, lease.token, nil
+	}
+	p.mu.Unlock()
+	return p.gen.GetIDContext(ctx)
+}
+
+func (p *PooledGenerator) Renew(workerID int64, token string) error {
+	return p.gen.Renew(workerID, token)
+}
+
+// RenewContext 同 Renew，使用调用方的 context
+func (p *PooledGenerator) RenewContext(ctx context.Context, workerID int64, token string) error {
+	return p.gen.RenewContext(ctx, workerID, token)
+}
+
+func (p *PooledGenerator) Release(workerID int64, token string) error {
+	return p.gen.Release(workerID, token)
+}
+
+// ReleaseContext 同 Release，使用调用方的 context
+func (p *PooledGenerator) ReleaseContext(ctx context.Context, workerID int64, token string) error {
+	return p.gen.ReleaseContext(ctx, workerID, token)
+}
+
+// Close 停止后台续期并释放所有未使用的备用 ID，已通过 GetID 返回的 ID 不受影响
+func (p *PooledGenerator) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	p.cancel()
+	<-p.done
+
+	p.mu.Lock()
+	standby := p.standby
+	p.standby = nil
+	p.mu.Unlock()
+
+	var errs []error
+	for _, lease := range standby {
+		if err := p.gen.ReleaseContext(ctx, lease.workerID, lease.token); err != nil && !isLeaseLost(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// run 定期续期备用 ID，并在备用 ID 被取走或续期失败后补充
+func (p *PooledGenerator) run(ctx context.Context) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.renew(ctx)
+			p.fill(ctx)
+		case <-p.refill:
+			p.fill(ctx)
+		}
+	}
+}
+
+// renew 续期所有备用 ID，移除租约已丢失的 ID
+func (p *PooledGenerator) renew(ctx context.Context) {
+	p.mu.Lock()
+	standby := append([]standbyLease(nil), p.standby...)
+	p.mu.Unlock()
+
+	for _, lease := range standby {
+		err := p.gen.RenewContext(ctx, lease.workerID, lease.token)
+		if err == nil || !isLeaseLost(err) {
+			continue
+		}
+		p.logger.WarnContext(ctx, "standby worker ID lost", slog.Int64("worker_id", lease.workerID),
+			slog.Any("error", err))
+		p.mu.Lock()
+		for i, l := range p.standby {
+			if l == lease {
+				p.standby = append(p.standby[:i], p.standby[i+1:]...)
+				break
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+// fill 获取备用 ID 直到数量达到 size，失败时留到下一次续期时重试
+func (p *PooledGenerator) fill(ctx context.Context) {
+	for {
+		p.mu.Lock()
+		if p.closed || len(p.standby) >= p.size {
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+
+		workerID, token, err := p.gen.GetIDContext(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				p.logger.WarnContext(ctx, "acquire standby worker ID failed", slog.Any("error", err))
+			}
+			return
+		}
+		p.mu.Lock()
+		p.standby = append(p.standby, standbyLease{workerID: workerID, token: token})
+		p.mu.Unlock()
+	}
+}
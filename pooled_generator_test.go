@@ -0,0 +1,115 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPooledGenerator(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(3))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	if _, err := NewPooledGenerator(gen, 0); err == nil {
+		t.Error("备用数量为 0 时应该返回错误")
+	}
+
+	pool, err := NewPooledGenerator(gen, 2)
+	if err != nil {
+		t.Fatalf("创建 PooledGenerator 失败: %v", err)
+	}
+	if n := pool.Standby(); n != 2 {
+		t.Fatalf("创建后应有 2 个备用 ID, 实际值: %d", n)
+	}
+
+	// 取走备用 ID 后在后台补充
+	workerID, token, err := pool.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if err := pool.Renew(workerID, token); err != nil {
+		t.Errorf("Renew() 失败: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for pool.Standby() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := pool.Standby(); n != 2 {
+		t.Errorf("取走后应补充到 2 个备用 ID, 实际值: %d", n)
+	}
+
+	stats, err := gen.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if stats.Leased != 3 {
+		t.Errorf("应有 3 个已分配的 ID（1 个在用，2 个备用）, 实际值: %d", stats.Leased)
+	}
+
+	// 被回收的备用 ID 在续期时移除并补充
+	pool.mu.Lock()
+	revoked := pool.standby[0].workerID
+	pool.mu.Unlock()
+	if err := gen.Revoke(ctx, revoked, "test", ""); err != nil {
+		t.Fatalf("Revoke() 失败: %v", err)
+	}
+	pool.renew(ctx)
+	pool.mu.Lock()
+	for _, lease := range pool.standby {
+		if lease.workerID == revoked {
+			t.Errorf("被回收的 WorkerID %d 应该从备用 ID 中移除", revoked)
+		}
+	}
+	pool.mu.Unlock()
+	pool.fill(ctx)
+	if n := pool.Standby(); n != 2 {
+		t.Errorf("续期后应补充到 2 个备用 ID, 实际值: %d", n)
+	}
+
+	// Close 释放未使用的备用 ID，已返回的 ID 不受影响
+	if err := pool.Close(ctx); err != nil {
+		t.Fatalf("Close() 失败: %v", err)
+	}
+	stats, err = gen.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if stats.Leased != 1 {
+		t.Errorf("Close 后应只剩 1 个已分配的 ID, 实际值: %d", stats.Leased)
+	}
+	if err := pool.Release(workerID, token); err != nil {
+		t.Errorf("Release() 失败: %v", err)
+	}
+}
+
+func TestPooledGenerator_Fallback(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(1))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	// 池只有 2 个 ID，备用 ID 只能获取到 2 个
+	pool, err := NewPooledGenerator(gen, 3)
+	if err != nil {
+		t.Fatalf("创建 PooledGenerator 失败: %v", err)
+	}
+	defer pool.Close(context.Background())
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := pool.GetID(); err != nil {
+			t.Fatalf("GetID() 失败: %v", err)
+		}
+	}
+	// 备用 ID 用完且无法补充时直接从 Redis 获取
+	if _, _, err := pool.GetID(); !errors.Is(err, ErrNoAvailableID) {
+		t.Errorf("池耗尽后应该返回 ErrNoAvailableID, 实际值: %v", err)
+	}
+}
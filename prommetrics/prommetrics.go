@@ -0,0 +1,113 @@
+// Package prommetrics 提供 workerid.Metrics 的 Prometheus 实现
+package prommetrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"libx.net/workerid"
+)
+
+// StatsSource 可以提供池统计信息的 Generator，如 *workerid.RedisGenerator
+type StatsSource interface {
+	Stats(ctx context.Context) (workerid.PoolStats, error)
+}
+
+// Metrics 实现 workerid.Metrics 和 prometheus.Collector
+type Metrics struct {
+	operations     *prometheus.CounterVec
+	scriptDuration *prometheus.HistogramVec
+	leased         *prometheus.GaugeVec
+	free           *prometheus.GaugeVec
+
+	mu      sync.Mutex
+	sources []StatsSource
+}
+
+var _ workerid.Metrics = (*Metrics)(nil)
+var _ prometheus.Collector = (*Metrics)(nil)
+
+// New 创建 Metrics，需要通过 prometheus.Registerer.Register 注册后才会被采集
+func New() *Metrics {
+	return &Metrics{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "workerid",
+			Name:      "operations_total",
+			Help:      "Number of GetID/Renew/Release operations by result.",
+		}, []string{"cluster", "operation", "result"}),
+		scriptDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "workerid",
+			Name:      "script_duration_seconds",
+			Help:      "Latency of Redis scripts executed by the generator.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"cluster", "script"}),
+		leased: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "workerid",
+			Name:      "pool_leased_ids",
+			Help:      "Number of worker IDs with a live lease.",
+		}, []string{"cluster"}),
+		free: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "workerid",
+			Name:      "pool_free_ids",
+			Help:      "Number of worker IDs available for allocation.",
+		}, []string{"cluster"}),
+	}
+}
+
+// OperationCompleted 实现 workerid.Metrics
+func (m *Metrics) OperationCompleted(cluster string, op workerid.Operation, err error) {
+	m.operations.WithLabelValues(cluster, string(op), workerid.ErrorKind(err)).Inc()
+}
+
+// ScriptExecuted 实现 workerid.Metrics
+func (m *Metrics) ScriptExecuted(cluster, script string, duration time.Duration) {
+	m.scriptDuration.WithLabelValues(cluster, script).Observe(duration.Seconds())
+}
+
+// PoolUsage 实现 workerid.Metrics
+func (m *Metrics) PoolUsage(cluster string, leased, free int64) {
+	m.leased.WithLabelValues(cluster).Set(float64(leased))
+	m.free.WithLabelValues(cluster).Set(float64(free))
+}
+
+// TrackPool 在每次采集时调用 source.Stats 刷新池使用量指标，隔离期较长或预留 ID 较多时应适当延长采集间隔
+func (m *Metrics) TrackPool(source StatsSource) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sources = append(m.sources, source)
+}
+
+// Describe 实现 prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.operations.Describe(ch)
+	m.scriptDuration.Describe(ch)
+	m.leased.Describe(ch)
+	m.free.Describe(ch)
+}
+
+// Collect 实现 prometheus.Collector
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.refreshPools()
+	m.operations.Collect(ch)
+	m.scriptDuration.Collect(ch)
+	m.leased.Collect(ch)
+	m.free.Collect(ch)
+}
+
+func (m *Metrics) refreshPools() {
+	m.mu.Lock()
+	sources := m.sources
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, source := range sources {
+		stats, err := source.Stats(ctx)
+		if err != nil {
+			continue
+		}
+		m.PoolUsage(stats.Cluster, stats.Leased, stats.Free+stats.Expired)
+	}
+}
@@ -0,0 +1,65 @@
+package prommetrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"libx.net/workerid"
+)
+
+func TestMetrics(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	m := New()
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(m); err != nil {
+		t.Fatalf("注册 Metrics 失败: %v", err)
+	}
+
+	gen, err := workerid.NewRedisGenerator(client, "prom-cluster", workerid.WithWorkerBits(2), workerid.WithMetrics(m))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	m.TrackPool(gen)
+
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if err := gen.Renew(workerID, token); err != nil {
+		t.Fatalf("Renew() 失败: %v", err)
+	}
+	if err := gen.Renew(workerID, "abcdefghijklmnopqrstuv"); err == nil {
+		t.Fatal("Renew() 使用错误的 Token 应该返回错误")
+	}
+
+	if v := testutil.ToFloat64(m.operations.WithLabelValues("prom-cluster", "get_id", "ok")); v != 1 {
+		t.Errorf("get_id 成功次数应该为 1, 实际值: %v", v)
+	}
+	if v := testutil.ToFloat64(m.operations.WithLabelValues("prom-cluster", "renew", "token_mismatch")); v != 1 {
+		t.Errorf("renew token_mismatch 次数应该为 1, 实际值: %v", v)
+	}
+	if n := testutil.CollectAndCount(m.scriptDuration); n != 3 {
+		t.Errorf("脚本耗时指标应该有 3 个序列 (init, get_id, renew), 实际值: %d", n)
+	}
+
+	// 采集时刷新池使用量
+	expected := `
+		# HELP workerid_pool_free_ids Number of worker IDs available for allocation.
+		# TYPE workerid_pool_free_ids gauge
+		workerid_pool_free_ids{cluster="prom-cluster"} 3
+		# HELP workerid_pool_leased_ids Number of worker IDs with a live lease.
+		# TYPE workerid_pool_leased_ids gauge
+		workerid_pool_leased_ids{cluster="prom-cluster"} 1
+	`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"workerid_pool_free_ids", "workerid_pool_leased_ids"); err != nil {
+		t.Error(err)
+	}
+}
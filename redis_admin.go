@@ -0,0 +1,632 @@
+package workerid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// LeaseState WorkerID 的租约状态
+type LeaseState string
+
+const (
+	// LeaseFree 空闲，可被分配
+	LeaseFree LeaseState = "free"
+	// LeaseLeased 已分配且租约有效
+	LeaseLeased LeaseState = "leased"
+	// LeaseExpired 租约已过期但未被释放，Token 记录仍然存在
+	LeaseExpired LeaseState = "expired"
+	// LeaseQuarantined 已释放或回收，但仍处于 WithReuseDelay 设置的隔离期内
+	LeaseQuarantined LeaseState = "quarantined"
+	// LeaseReserved 通过 WithReservedRange 或 WithStaticAssignment 预留，不参与分配
+	LeaseReserved LeaseState = "reserved"
+)
+
+// Lease 单个 WorkerID 的租约信息
+type Lease struct {
+	WorkerID int64      `json:"worker_id"`
+	State    LeaseState `json:"state"`
+	// ExpireAt 租约到期时间，空闲 ID 为零值
+	ExpireAt time.Time `json:"expire_at"`
+	// ReusableAt 隔离期结束时间，仅隔离期内的 ID 有值
+	ReusableAt time.Time `json:"reusable_at"`
+	// Holder 持有者信息，分配时未提供则为 nil
+	Holder *Holder `json:"holder,omitempty"`
+	// Reservation 静态分配的持有者名称，仅预留的 ID 有值
+	Reservation string `json:"reservation,omitempty"`
+}
+
+// PoolStats WorkerID 池的汇总统计
+type PoolStats struct {
+	Cluster string `json:"cluster"`
+	Total   int64  `json:"total"`
+	Free    int64  `json:"free"`
+	Leased  int64  `json:"leased"`
+	Expired int64  `json:"expired"`
+	// Quarantined 已释放或回收但仍处于隔离期的 ID 数量
+	Quarantined int64 `json:"quarantined"`
+	// Reserved 预留的 ID 数量，不计入 Total
+	Reserved int64 `json:"reserved"`
+}
+
+// Reservation 预留的 WorkerID
+type Reservation struct {
+	WorkerID int64 `json:"worker_id"`
+	// Name 静态分配的持有者名称，通过 WithReservedRange 预留的 ID 为空
+	Name string `json:"name,omitempty"`
+}
+
+// OpenRedisGenerator 打开已有的池用于查看和管理，不会初始化或修改池。
+// 池的最大 WorkerID 和存储方式以 Redis 中记录的元数据为准，options 中的 WithWorkerBits 和 WithPoolMode
+// 只在池没有元数据（由旧版本创建或尚未创建）时使用
+func OpenRedisGenerator(ctx context.Context, redisClient *redis.Client, cluster string,
+	options ...Option) (*RedisGenerator, error) {
+	g, err := newRedisGenerator(redisClient, cluster, options...)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := redisClient.HMGet(ctx, g.getMetaKey(), "max_worker_id", "mode").Result()
+	if err != nil {
+		return nil, fmt.Errorf("get pool metadata failed: %w", err)
+	}
+	if s, ok := meta[0].(string); ok {
+		maxID, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max worker ID %q in pool metadata", s)
+		}
+		workerBits := uint(bits.Len32(uint32(maxID)))
+		if g.datacenterBits+workerBits > 31 {
+			return nil, fmt.Errorf("invalid datacenter bits %d and machine bits %d", g.datacenterBits, workerBits)
+		}
+		g.maxWorkerID = uint32(maxID)
+		g.machineBits = workerBits
+	}
+	if mode, ok := meta[1].(string); ok {
+		g.poolMode = PoolMode(mode)
+	}
+	return g, nil
+}
+
+// ListLeases 列出池中所有 WorkerID 的租约状态，按 WorkerID 升序排列。
+// PoolModeLazy 下只包含分配过的 ID 和预留的 ID
+func (g *RedisGenerator) ListLeases(ctx context.Context) ([]Lease, error) {
+	now, err := g.getCurrentTime(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get current time failed: %w", err)
+	}
+
+	pipe := g.redisClient.Pipeline()
+	idsCmd := pipe.ZRangeWithScores(ctx, g.getIDsKey(), 0, -1)
+	tokensCmd := pipe.HGetAll(ctx, g.getTokenKey())
+	holdersCmd := pipe.HGetAll(ctx, g.getHolderKey())
+	reservedCmd := pipe.HGetAll(ctx, g.getReservedKey())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("list leases failed: %w", err)
+	}
+
+	tokens := tokensCmd.Val()
+	holders := holdersCmd.Val()
+	reserved := reservedCmd.Val()
+	leases := make([]Lease, 0, len(idsCmd.Val())+len(reserved))
+	for _, z := range idsCmd.Val() {
+		member, _ := z.Member.(string)
+		workerID, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		lease := Lease{WorkerID: g.composeID(workerID), State: LeaseFree}
+		// 预留前已分配、尚未释放的 ID 仍在池中，保留其租约状态
+		if name, ok := reserved[member]; ok {
+			lease.Reservation = name
+			delete(reserved, member)
+		}
+		tokenStr, ok := tokens[member]
+		if !ok && int64(z.Score) > now-g.reuseDelay && int64(z.Score) <= now {
+			lease.State = LeaseQuarantined
+			lease.ReusableAt = time.Unix(int64(z.Score)+g.reuseDelay, 0)
+		}
+		if ok {
+			expireAt, err := parseTokenExpire(tokenStr)
+			if err != nil {
+				return nil, err
+			}
+			lease.ExpireAt = time.Unix(expireAt, 0)
+			if expireAt > now {
+				lease.State = LeaseLeased
+			} else {
+				lease.State = LeaseExpired
+			}
+			if holderData, ok := holders[member]; ok {
+				holder := &Holder{}
+				if err := json.Unmarshal([]byte(holderData), holder); err == nil {
+					lease.Holder = holder
+				}
+			}
+		}
+		leases = append(leases, lease)
+	}
+	for member, name := range reserved {
+		workerID, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		leases = append(leases, Lease{WorkerID: g.composeID(workerID), State: LeaseReserved, Reservation: name})
+	}
+	sort.Slice(leases, func(i, j int) bool {
+		return leases[i].WorkerID < leases[j].WorkerID
+	})
+
+	return leases, nil
+}
+
+var statsScript = redis.NewScript(`
+	local key = KEYS[1]
+	local tokenKey = KEYS[2]
+	local now = tonumber(ARGV[1])
+
+	local cutoff = tonumber(ARGV[2])
+
+	local total = redis.call('ZCARD', key)
+	local leased = redis.call('ZCOUNT', key, '(' .. now, '+inf')
+	local tokens = redis.call('HLEN', tokenKey)
+	local reserved = redis.call('HLEN', KEYS[3])
+
+	-- lazy 模式下游标之后未预留的 ID 尚未写入池中，同样计入总数
+	if ARGV[3] == 'lazy' then
+		local maxID = tonumber(ARGV[4])
+		local next = tonumber(redis.call('GET', KEYS[4]) or '0')
+		if next <= maxID then
+			total = total + maxID - next + 1
+			for _, workerID in ipairs(redis.call('HKEYS', KEYS[3])) do
+				if tonumber(workerID) >= next and tonumber(workerID) <= maxID then
+					total = total - 1
+				end
+			end
+		end
+	elseif ARGV[3] == 'bitmap' then
+		total = total + tonumber(ARGV[4]) + 1 - redis.call('BITCOUNT', KEYS[5])
+	end
+
+	-- 隔离期内且没有 Token 记录的 ID，数量受隔离期内释放的 ID 数量限制
+	local quarantined = 0
+	if cutoff < now then
+		local ids = redis.call('ZRANGEBYSCORE', key, '(' .. cutoff, now)
+		for _, workerID in ipairs(ids) do
+			if redis.call('HEXISTS', tokenKey, workerID) == 0 then
+				quarantined = quarantined + 1
+			end
+		end
+	end
+
+	return {total, leased, tokens, quarantined, reserved}
+`)
+
+// Stats 获取池的汇总统计。总数和租约数使用计数命令，隔离期内的 ID 需要逐个检查 Token 记录，
+// lazy 模式下还会读取全部预留 ID，开销随隔离期内释放的 ID 数量和预留 ID 数量增长
+func (g *RedisGenerator) Stats(ctx context.Context) (PoolStats, error) {
+	stats := PoolStats{Cluster: g.cluster}
+	now, err := g.getCurrentTime(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("get current time failed: %w", err)
+	}
+
+	keys := []string{g.getIDsKey(), g.getTokenKey(), g.getReservedKey(), g.getCursorKey(), g.getBitmapKey()}
+	counts, err := g.runScript(ctx, statsScript, "stats", keys,
+		now, now-g.reuseDelay, string(g.poolMode), g.maxWorkerID).Int64Slice()
+	if err != nil {
+		return stats, fmt.Errorf("get stats failed: %w", err)
+	}
+
+	// 每个有效租约都有 Token 记录，剩余的 Token 记录即为已过期未释放的租约
+	stats.Total = counts[0]
+	stats.Leased = counts[1]
+	stats.Expired = max(counts[2]-counts[1], 0)
+	stats.Quarantined = counts[3]
+	stats.Reserved = counts[4]
+	stats.Free = max(stats.Total-stats.Leased-stats.Expired-stats.Quarantined, 0)
+	g.metrics.PoolUsage(g.metricsLabel, stats.Leased, stats.Free+stats.Expired)
+	return stats, nil
+}
+
+// parseTokenExpire 从 "token:expireAt" 格式的 Token 记录中解析过期时间
+func parseTokenExpire(tokenStr string) (int64, error) {
+	_, expireAtStr, ok := strings.Cut(tokenStr, ":")
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+	expireAt, err := strconv.ParseInt(expireAtStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid expire_at format: %w", err)
+	}
+	return expireAt, nil
+}
+
+// auditLogSize 审计日志保留的最大条数
+const auditLogSize = 1000
+
+// AuditEntry 管理操作的审计记录
+type AuditEntry struct {
+	Action   string `json:"action"`
+	WorkerID int64  `json:"worker_id"`
+	Operator string `json:"operator"`
+	Reason   string `json:"reason,omitempty"`
+	// Token 被撤销的 Token
+	Token  string  `json:"token,omitempty"`
+	Holder *Holder `json:"holder,omitempty"`
+	Time   int64   `json:"time"`
+}
+
+var revokeScript = redis.NewScript(`
+	local key = KEYS[1]
+	local tokenKey = KEYS[2]
+	local holderKey = KEYS[3]
+	local auditKey = KEYS[4]
+	local workerID = ARGV[1]
+
+	local tokenStr = redis.call('HGET', tokenKey, workerID)
+	if not tokenStr then
+		return {err="Token not found"}
+	end
+	local colonPos = string.find(tokenStr, ":")
+	local token = tokenStr
+	if colonPos then
+		token = string.sub(tokenStr, 1, colonPos-1)
+	end
+
+	local entry = {
+		action = 'revoke',
+		worker_id = tonumber(workerID),
+		operator = ARGV[2],
+		reason = ARGV[3],
+		token = token,
+		time = tonumber(ARGV[4]),
+	}
+	local holder = redis.call('HGET', holderKey, workerID)
+	if holder then
+		entry['holder'] = cjson.decode(holder)
+	end
+
+	-- 删除 Token 和持有者记录，并将 ID 标记为可用或进入隔离期，已被预留的 ID 直接移出池
+	redis.call('HDEL', tokenKey, workerID)
+	redis.call('HDEL', holderKey, workerID)
+	if redis.call('HEXISTS', KEYS[6], workerID) == 1 then
+		redis.call('ZREM', key, workerID)
+	elseif ARGV[7] == 'bitmap' and tonumber(ARGV[6]) == 0 then
+		redis.call('ZREM', key, workerID)
+		redis.call('SETBIT', KEYS[7], workerID, 0)
+	else
+		redis.call('ZADD', key, tonumber(ARGV[6]), workerID)
+	end
+
+	redis.call('PUBLISH', KEYS[5], cjson.encode({type='revoked', worker_id=entry.worker_id, time=entry.time}))
+
+	redis.call('LPUSH', auditKey, cjson.encode(entry))
+	redis.call('LTRIM', auditKey, 0, tonumber(ARGV[5]) - 1)
+
+	return {ok="Success"}
+`)
+
+// Revoke 强制回收 WorkerID，无需持有者的 Token。
+// 回收后原持有者的下一次 Renew 将返回 ErrNotAssigned，操作人和原因会记录到审计日志中。
+func (g *RedisGenerator) Revoke(ctx context.Context, workerID int64, operator, reason string) error {
+	id, err := g.machineID(workerID)
+	if err != nil {
+		return err
+	}
+	if operator == "" {
+		return errors.New("operator is empty")
+	}
+
+	now, err := g.getCurrentTime(ctx)
+	if err != nil {
+		return fmt.Errorf("get current time failed: %w", err)
+	}
+
+	keys := []string{g.getIDsKey(), g.getTokenKey(), g.getHolderKey(), g.getAuditKey(), g.getEventChannel(),
+		g.getReservedKey(), g.getBitmapKey()}
+	err = g.runScript(ctx, revokeScript, "revoke", keys,
+		id, operator, reason, now, auditLogSize, g.releaseScore(now), string(g.poolMode)).Err()
+	if err != nil {
+		if scriptErr := parseScriptError(err); scriptErr != nil {
+			return scriptErr
+		}
+		return fmt.Errorf("revoke failed: %w", err)
+	}
+	g.logger.WarnContext(ctx, "worker ID revoked", slog.Int64("worker_id", workerID),
+		slog.String("operator", operator), slog.String("reason", reason))
+	return nil
+}
+
+// AuditLog 获取最近的管理操作审计记录，按时间倒序排列，limit <= 0 时返回全部
+func (g *RedisGenerator) AuditLog(ctx context.Context, limit int) ([]AuditEntry, error) {
+	stop := int64(-1)
+	if limit > 0 {
+		stop = int64(limit) - 1
+	}
+	items, err := g.redisClient.LRange(ctx, g.getAuditKey(), 0, stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get audit log failed: %w", err)
+	}
+
+	entries := make([]AuditEntry, 0, len(items))
+	for _, item := range items {
+		var entry AuditEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			return nil, fmt.Errorf("decode audit entry failed: %w", err)
+		}
+		entry.WorkerID = g.composeID(entry.WorkerID)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+var resizeScript = redis.NewScript(`
+	local key = KEYS[1]
+	local tokenKey = KEYS[2]
+	local holderKey = KEYS[3]
+	local newMax = tonumber(ARGV[1])
+	local now = tonumber(ARGV[2])
+	local force = ARGV[3] == '1'
+
+	-- 1. 找出超出新范围的 ID，未强制时拒绝回收仍在租约期内的 ID
+	local removed = {}
+	local members = redis.call('ZRANGE', key, 0, -1, 'WITHSCORES')
+	for i = 1, #members, 2 do
+		if tonumber(members[i]) > newMax then
+			if not force and tonumber(members[i+1]) > now then
+				return {err="Worker ID in use"}
+			end
+			table.insert(removed, members[i])
+		end
+	end
+
+	-- 2. 移除超出范围的 ID 及其 Token 和持有者记录，bitmap 模式下同时清除在用标记
+	for _, workerID in ipairs(removed) do
+		redis.call('ZREM', key, workerID)
+		redis.call('HDEL', tokenKey, workerID)
+		redis.call('HDEL', holderKey, workerID)
+		if ARGV[5] == 'bitmap' then
+			redis.call('SETBIT', KEYS[7], workerID, 0)
+		end
+	end
+
+	-- 3. eager 模式下记录新的范围并清除版本标记，由调用方分批补齐新范围内缺失的 ID 后再写入版本标记，
+	-- 避免长时间阻塞 Redis。lazy 模式下只需将游标退回新范围内，扩容后被移除的 ID 可以重新由游标发放，
+	-- bitmap 模式下无需处理
+	if ARGV[5] == 'eager' then
+		redis.call('HSET', KEYS[5], 'max_worker_id', newMax)
+		redis.call('HDEL', KEYS[5], 'version')
+		return #removed
+	elseif ARGV[5] == 'lazy' then
+		local next = tonumber(redis.call('GET', KEYS[6]) or '0')
+		if next > newMax + 1 then
+			redis.call('SET', KEYS[6], newMax + 1)
+		end
+	end
+	redis.call('HSET', KEYS[5], 'version', ARGV[4], 'max_worker_id', newMax)
+
+	return #removed
+`)
+
+// Resize 调整池的大小，workerBits 含义与 WithWorkerBits 相同，设置了 WithDatacenter 时为机器 ID 的位数。
+// 缩容时若被移除的 ID 仍在租约期内则返回 ErrWorkerIDInUse，force 为 true 时强制回收。
+// eager 模式下与初始化一样分批补齐新范围内的 ID，全部写入后才记录版本，中途失败时可以重新执行 Resize 补齐。
+// Resize 只修改 Redis 中的池，不修改当前及其他实例的配置，之后需要使用新的位数重新创建 RedisGenerator，
+// 使用旧的位数创建会因与池的元数据不一致而失败
+func (g *RedisGenerator) Resize(ctx context.Context, workerBits uint, force bool) error {
+	if workerBits == 0 || g.datacenterBits+workerBits > 31 {
+		return fmt.Errorf("invalid worker bits: %d", workerBits)
+	}
+	newMax := uint32(1)<<workerBits - 1
+
+	now, err := g.getCurrentTime(ctx)
+	if err != nil {
+		return fmt.Errorf("get current time failed: %w", err)
+	}
+
+	forceArg := "0"
+	if force {
+		forceArg = "1"
+	}
+	keys := []string{g.getIDsKey(), g.getTokenKey(), g.getHolderKey(), g.getReservedKey(), g.getMetaKey(),
+		g.getCursorKey(), g.getBitmapKey()}
+	err = g.runScript(ctx, resizeScript, "resize", keys,
+		newMax, now, forceArg, poolVersion, string(g.poolMode)).Err()
+	if err != nil {
+		if err.Error() == "Worker ID in use" {
+			return ErrWorkerIDInUse
+		}
+		return fmt.Errorf("resize failed: %w", err)
+	}
+	if g.poolMode == PoolModeEager {
+		if _, err := g.fillPool(ctx, newMax); err != nil {
+			return fmt.Errorf("fill resized pool failed: %w", err)
+		}
+	}
+
+	g.logger.InfoContext(ctx, "worker ID pool resized", slog.Uint64("max_worker_id", uint64(newMax)))
+	return nil
+}
+
+// Reservations 列出所有预留的 WorkerID，按 WorkerID 升序排列
+func (g *RedisGenerator) Reservations(ctx context.Context) ([]Reservation, error) {
+	reserved, err := g.redisClient.HGetAll(ctx, g.getReservedKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get reservations failed: %w", err)
+	}
+
+	reservations := make([]Reservation, 0, len(reserved))
+	for member, name := range reserved {
+		workerID, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		reservations = append(reservations, Reservation{WorkerID: g.composeID(workerID), Name: name})
+	}
+	sort.Slice(reservations, func(i, j int) bool {
+		return reservations[i].WorkerID < reservations[j].WorkerID
+	})
+	return reservations, nil
+}
+
+var unreserveScript = redis.NewScript(`
+	local key = KEYS[1]
+	local workerID = ARGV[1]
+	local id = tonumber(workerID)
+
+	if redis.call('HDEL', KEYS[2], workerID) == 0 then
+		return {err="Worker ID not reserved"}
+	end
+
+	-- 将 ID 放回池中，仍在池中的 ID（租约期内或隔离期内）释放后照常可用。
+	-- lazy 模式下游标之后的 ID 由游标发放，bitmap 模式下清除在用标记即可
+	if not redis.call('ZSCORE', key, workerID) then
+		if ARGV[2] == 'bitmap' then
+			redis.call('SETBIT', KEYS[3], id, 0)
+		elseif ARGV[2] == 'eager' or id < tonumber(redis.call('GET', KEYS[4]) or '0') then
+			redis.call('ZADD', key, 0, workerID)
+		end
+	end
+
+	local entry = {
+		action = 'unreserve',
+		worker_id = id,
+		operator = ARGV[3],
+		reason = ARGV[4],
+		time = tonumber(ARGV[5]),
+	}
+	redis.call('LPUSH', KEYS[5], cjson.encode(entry))
+	redis.call('LTRIM', KEYS[5], 0, tonumber(ARGV[6]) - 1)
+	return {ok="Success"}
+`)
+
+// Unreserve 取消 WorkerID 的预留并将其放回池中，WorkerID 未被预留时返回 ErrNotReserved。
+// 预留只会在创建 RedisGenerator 时增加，从 WithReservedRange 或 WithStaticAssignment 中移除 ID 后
+// 需要调用 Unreserve 归还，Reset 不会清除预留。仍使用旧配置的实例重新创建时会再次预留该 ID，
+// 因此应在所有实例更新配置后再调用。操作人和原因会记录到审计日志中
+func (g *RedisGenerator) Unreserve(ctx context.Context, workerID int64, operator, reason string) error {
+	id, err := g.machineID(workerID)
+	if err != nil {
+		return err
+	}
+	if operator == "" {
+		return errors.New("operator is empty")
+	}
+
+	now, err := g.getCurrentTime(ctx)
+	if err != nil {
+		return fmt.Errorf("get current time failed: %w", err)
+	}
+
+	keys := []string{g.getIDsKey(), g.getReservedKey(), g.getBitmapKey(), g.getCursorKey(), g.getAuditKey()}
+	err = g.runScript(ctx, unreserveScript, "unreserve", keys,
+		id, string(g.poolMode), operator, reason, now, auditLogSize).Err()
+	if err != nil {
+		if err.Error() == "Worker ID not reserved" {
+			return ErrNotReserved
+		}
+		return fmt.Errorf("unreserve failed: %w", err)
+	}
+	g.logger.WarnContext(ctx, "worker ID unreserved", slog.Int64("worker_id", workerID),
+		slog.String("operator", operator), slog.String("reason", reason))
+	return nil
+}
+
+var resetScript = redis.NewScript(`
+	local key = KEYS[1]
+	local now = tonumber(ARGV[1])
+	local maxID = tonumber(ARGV[2])
+	local force = ARGV[3] == '1'
+	local rebuild = ARGV[4] == '1'
+
+	-- 1. 未强制时拒绝清除仍在租约期内的 ID
+	local leased = redis.call('ZCOUNT', key, '(' .. now, '+inf')
+	if not force and leased > 0 then
+		return {err="Worker ID in use"}
+	end
+
+	-- 2. 删除池、游标、位图及 Token 和持有者记录，销毁时同时删除预留、审计记录和元数据
+	redis.call('DEL', key, KEYS[2], KEYS[3], KEYS[7], KEYS[8])
+	if not rebuild then
+		redis.call('DEL', KEYS[4], KEYS[5], KEYS[6])
+		return leased
+	end
+
+	-- 3. 重建池，eager 模式下删除元数据，由调用方分批写入 ID 后再写入元数据，避免长时间阻塞 Redis；
+	-- lazy 模式下清空游标即可，bitmap 模式下重新标记预留的 ID
+	if ARGV[6] == 'eager' then
+		redis.call('DEL', KEYS[6])
+		return leased
+	elseif ARGV[6] == 'bitmap' then
+		for _, workerID in ipairs(redis.call('HKEYS', KEYS[4])) do
+			redis.call('SETBIT', KEYS[8], workerID, 1)
+		end
+	end
+	redis.call('HSET', KEYS[6], 'version', ARGV[5], 'max_worker_id', maxID, 'mode', ARGV[6])
+	return leased
+`)
+
+// Reset 清除池中所有租约并重建池，保留预留 ID 和审计记录，预留 ID 需要通过 Unreserve 取消。
+// 存在有效租约时返回 ErrWorkerIDInUse，force 为 true 时强制清除，原持有者的下一次 Renew 将返回 ErrNotAssigned。
+// eager 模式下在一个脚本中清除池和元数据后分批写入 ID，重建期间 GetID 可能暂时取不到空闲 ID，
+// 中途失败时下次创建 RedisGenerator 会重新补齐
+func (g *RedisGenerator) Reset(ctx context.Context, force bool) error {
+	leased, err := g.reset(ctx, force, true)
+	if err != nil {
+		return err
+	}
+	g.logger.WarnContext(ctx, "worker ID pool reset", slog.Int64("leased", leased))
+	return nil
+}
+
+// Destroy 删除池的所有数据，包括预留 ID 和审计记录，之后 GetID 将返回 ErrNoAvailableID，
+// 直到使用相同的集群名称重新创建 RedisGenerator。有效租约的处理与 Reset 相同
+func (g *RedisGenerator) Destroy(ctx context.Context, force bool) error {
+	leased, err := g.reset(ctx, force, false)
+	if err != nil {
+		return err
+	}
+	g.logger.WarnContext(ctx, "worker ID pool destroyed", slog.Int64("leased", leased))
+	return nil
+}
+
+// reset 在一个脚本中清除池，rebuild 为 true 时重建池，eager 模式下清除后分批写入 ID，返回被清除的有效租约数量
+func (g *RedisGenerator) reset(ctx context.Context, force, rebuild bool) (int64, error) {
+	now, err := g.getCurrentTime(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("get current time failed: %w", err)
+	}
+
+	forceArg, rebuildArg := "0", "0"
+	if force {
+		forceArg = "1"
+	}
+	if rebuild {
+		rebuildArg = "1"
+	}
+	keys := []string{g.getIDsKey(), g.getTokenKey(), g.getHolderKey(), g.getReservedKey(), g.getAuditKey(),
+		g.getMetaKey(), g.getCursorKey(), g.getBitmapKey()}
+	leased, err := g.runScript(ctx, resetScript, "reset", keys,
+		now, g.maxWorkerID, forceArg, rebuildArg, poolVersion, string(g.poolMode)).Int64()
+	if err != nil {
+		if err.Error() == "Worker ID in use" {
+			return 0, ErrWorkerIDInUse
+		}
+		return 0, fmt.Errorf("reset failed: %w", err)
+	}
+	if rebuild && g.poolMode == PoolModeEager {
+		if _, err := g.fillPool(ctx, g.maxWorkerID); err != nil {
+			return leased, fmt.Errorf("rebuild pool failed: %w", err)
+		}
+	}
+	return leased, nil
+}
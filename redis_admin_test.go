@@ -0,0 +1,553 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRedisGenerator_ListLeases(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	workerID, _, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+
+	// 构造一个已过期但未释放的租约
+	ctx := context.Background()
+	expiredID := (workerID + 1) % 4
+	expireAt := time.Now().Add(-time.Minute).Unix()
+	if err := client.HSet(ctx, gen.getTokenKey(), strconv.FormatInt(expiredID, 10),
+		"abcdefghijklmnopqrstuv:"+strconv.FormatInt(expireAt, 10)).Err(); err != nil {
+		t.Fatalf("写入过期 Token 失败: %v", err)
+	}
+
+	leases, err := gen.ListLeases(ctx)
+	if err != nil {
+		t.Fatalf("ListLeases() 失败: %v", err)
+	}
+	if len(leases) != 4 {
+		t.Fatalf("租约数量应该为 4, 实际值: %d", len(leases))
+	}
+
+	for i, lease := range leases {
+		if lease.WorkerID != int64(i) {
+			t.Errorf("租约应按 WorkerID 升序排列, 位置 %d 的 WorkerID 为 %d", i, lease.WorkerID)
+		}
+		switch lease.WorkerID {
+		case workerID:
+			if lease.State != LeaseLeased {
+				t.Errorf("WorkerID %d 的状态应该为 leased, 实际值: %s", lease.WorkerID, lease.State)
+			}
+			if !lease.ExpireAt.After(time.Now()) {
+				t.Errorf("已分配的租约过期时间应该在未来, 实际值: %v", lease.ExpireAt)
+			}
+		case expiredID:
+			if lease.State != LeaseExpired {
+				t.Errorf("WorkerID %d 的状态应该为 expired, 实际值: %s", lease.WorkerID, lease.State)
+			}
+			if lease.ExpireAt.Unix() != expireAt {
+				t.Errorf("过期时间应该为 %d, 实际值: %d", expireAt, lease.ExpireAt.Unix())
+			}
+		default:
+			if lease.State != LeaseFree {
+				t.Errorf("WorkerID %d 的状态应该为 free, 实际值: %s", lease.WorkerID, lease.State)
+			}
+			if !lease.ExpireAt.IsZero() {
+				t.Errorf("空闲 ID 的过期时间应该为零值, 实际值: %v", lease.ExpireAt)
+			}
+		}
+	}
+}
+
+func TestRedisGenerator_Stats(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(3))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	ctx := context.Background()
+	stats, err := gen.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if stats.Total != 8 || stats.Free != 8 || stats.Leased != 0 || stats.Expired != 0 {
+		t.Errorf("初始统计不正确: %+v", stats)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := gen.GetID(); err != nil {
+			t.Fatalf("GetID() 失败: %v", err)
+		}
+	}
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if err := gen.Release(workerID, token); err != nil {
+		t.Fatalf("Release() 失败: %v", err)
+	}
+
+	// 将一个已分配的 ID 标记为过期
+	idsKey := gen.getIDsKey()
+	leased, err := client.ZRevRangeWithScores(ctx, idsKey, 0, 0).Result()
+	if err != nil || len(leased) == 0 {
+		t.Fatalf("获取已分配 ID 失败: %v", err)
+	}
+	member, _ := leased[0].Member.(string)
+	expireAt := time.Now().Add(-time.Minute).Unix()
+	if err := client.ZAdd(ctx, idsKey, &redis.Z{Score: float64(expireAt), Member: member}).Err(); err != nil {
+		t.Fatalf("更新过期时间失败: %v", err)
+	}
+	if err := client.HSet(ctx, gen.getTokenKey(), member,
+		"abcdefghijklmnopqrstuv:"+strconv.FormatInt(expireAt, 10)).Err(); err != nil {
+		t.Fatalf("写入过期 Token 失败: %v", err)
+	}
+
+	stats, err = gen.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if stats.Cluster != "test-cluster" {
+		t.Errorf("Cluster 应该为 test-cluster, 实际值: %s", stats.Cluster)
+	}
+	if stats.Total != 8 || stats.Leased != 2 || stats.Expired != 1 || stats.Free != 5 {
+		t.Errorf("统计不正确: %+v", stats)
+	}
+}
+
+func TestRedisGenerator_Revoke(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2),
+		WithHolder(Holder{Hostname: "zombie-host", PID: 7}))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := gen.Revoke(ctx, workerID, "", "missing operator"); err == nil {
+		t.Error("Revoke() 未指定操作人应该返回错误")
+	}
+	if err := gen.Revoke(ctx, -1, "alice", "invalid"); !errors.Is(err, ErrInvalidWorkerID) {
+		t.Errorf("Revoke() 无效 WorkerID 应该返回 ErrInvalidWorkerID, 实际值: %v", err)
+	}
+	if err := gen.Revoke(ctx, workerID, "alice", "zombie process"); err != nil {
+		t.Fatalf("Revoke() 失败: %v", err)
+	}
+
+	// 原持有者续期应该失败
+	if err := gen.Renew(workerID, token); !errors.Is(err, ErrNotAssigned) {
+		t.Errorf("撤销后 Renew() 应该返回 ErrNotAssigned, 实际值: %v", err)
+	}
+
+	// ID 应该重新变为可用
+	score, err := client.ZScore(ctx, gen.getIDsKey(), strconv.FormatInt(workerID, 10)).Result()
+	if err != nil {
+		t.Fatalf("获取 ID 分数失败: %v", err)
+	}
+	if score != 0 {
+		t.Errorf("撤销后 ID 的分数应该为 0, 实际值: %f", score)
+	}
+	exists, err := client.HExists(ctx, gen.getHolderKey(), strconv.FormatInt(workerID, 10)).Result()
+	if err != nil {
+		t.Fatalf("检查持有者信息失败: %v", err)
+	}
+	if exists {
+		t.Error("撤销后持有者信息应该已移除")
+	}
+
+	// 重复撤销应该返回 ErrNotAssigned
+	if err := gen.Revoke(ctx, workerID, "alice", "again"); !errors.Is(err, ErrNotAssigned) {
+		t.Errorf("重复 Revoke() 应该返回 ErrNotAssigned, 实际值: %v", err)
+	}
+
+	entries, err := gen.AuditLog(ctx, 10)
+	if err != nil {
+		t.Fatalf("AuditLog() 失败: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("审计记录数量应该为 1, 实际值: %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Action != "revoke" || entry.WorkerID != workerID || entry.Operator != "alice" ||
+		entry.Reason != "zombie process" || entry.Token != token {
+		t.Errorf("审计记录不正确: %+v", entry)
+	}
+	if entry.Holder == nil || entry.Holder.Hostname != "zombie-host" || entry.Holder.PID != 7 {
+		t.Errorf("审计记录中的持有者信息不正确: %+v", entry.Holder)
+	}
+	if entry.Time <= 0 {
+		t.Errorf("审计记录时间应该大于 0, 实际值: %d", entry.Time)
+	}
+}
+
+func TestRedisGenerator_Resize(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(3))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	ctx := context.Background()
+	idsKey := gen.getIDsKey()
+
+	// 占用最大的 ID
+	if err := client.ZAdd(ctx, idsKey, &redis.Z{Score: float64(time.Now().Add(time.Minute).Unix()), Member: "7"}).Err(); err != nil {
+		t.Fatalf("设置过期时间失败: %v", err)
+	}
+
+	// 扩容保留已有租约
+	if err := gen.Resize(ctx, 4, false); err != nil {
+		t.Fatalf("Resize() 扩容失败: %v", err)
+	}
+	if n, _ := client.ZCard(ctx, idsKey).Result(); n != 16 {
+		t.Errorf("扩容后 ID 数量应该为 16, 实际值: %d", n)
+	}
+	if score, _ := client.ZScore(ctx, idsKey, "7").Result(); score == 0 {
+		t.Error("扩容不应该重置已分配 ID 的过期时间")
+	}
+	if gen.maxWorkerID != 7 {
+		t.Errorf("Resize 不应修改当前实例的 maxWorkerID, 实际值: %d", gen.maxWorkerID)
+	}
+	if max := client.HGet(ctx, gen.getMetaKey(), "max_worker_id").Val(); max != "15" {
+		t.Errorf("扩容后元数据中的 max_worker_id 应该为 15, 实际值: %s", max)
+	}
+
+	// 缩容时被移除的 ID 仍在租约期内
+	if err := gen.Resize(ctx, 2, false); !errors.Is(err, ErrWorkerIDInUse) {
+		t.Errorf("缩容移除已分配 ID 应该返回 ErrWorkerIDInUse, 实际值: %v", err)
+	}
+	if n, _ := client.ZCard(ctx, idsKey).Result(); n != 16 {
+		t.Errorf("缩容失败时 ID 数量不应该变化, 实际值: %d", n)
+	}
+
+	// 强制缩容
+	if err := gen.Resize(ctx, 2, true); err != nil {
+		t.Fatalf("Resize() 强制缩容失败: %v", err)
+	}
+	if n, _ := client.ZCard(ctx, idsKey).Result(); n != 4 {
+		t.Errorf("缩容后 ID 数量应该为 4, 实际值: %d", n)
+	}
+	if _, err := client.ZScore(ctx, idsKey, "7").Result(); !errors.Is(err, redis.Nil) {
+		t.Error("缩容后超出范围的 ID 应该已移除")
+	}
+
+	if err := gen.Resize(ctx, 0, false); err == nil {
+		t.Error("Resize() 无效的 workerBits 应该返回错误")
+	}
+}
+
+func TestRedisGenerator_Reservations(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// 预留前先分配 ID 5，模拟预留时仍在租约期内的 ID
+	plain, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(3))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	for i := 0; i < 6; i++ {
+		if _, _, err := plain.GetID(); err != nil {
+			t.Fatalf("GetID() 失败: %v", err)
+		}
+	}
+	token5, err := client.HGet(ctx, plain.getTokenKey(), "5").Result()
+	if err != nil {
+		t.Fatalf("读取 WorkerID 5 的 Token 失败: %v", err)
+	}
+	for i := int64(0); i < 5; i++ {
+		if err := plain.Revoke(ctx, i, "test", ""); err != nil {
+			t.Fatalf("Revoke(%d) 失败: %v", i, err)
+		}
+	}
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(3),
+		WithReservedRange(0, 3), WithStaticAssignment(5, "scheduler"))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	reservations, err := gen.Reservations(ctx)
+	if err != nil {
+		t.Fatalf("Reservations() 失败: %v", err)
+	}
+	want := []Reservation{{WorkerID: 0}, {WorkerID: 1}, {WorkerID: 2}, {WorkerID: 3}, {WorkerID: 5, Name: "scheduler"}}
+	if len(reservations) != len(want) {
+		t.Fatalf("预留数量应该为 %d, 实际值: %d", len(want), len(reservations))
+	}
+	for i := range want {
+		if reservations[i] != want[i] {
+			t.Errorf("预留信息应该为 %+v, 实际值: %+v", want[i], reservations[i])
+		}
+	}
+
+	// 仍在租约期内的 ID 5 保留租约状态，其余预留 ID 标记为 reserved
+	leases, err := gen.ListLeases(ctx)
+	if err != nil {
+		t.Fatalf("ListLeases() 失败: %v", err)
+	}
+	if len(leases) != 8 {
+		t.Fatalf("租约数量应该为 8, 实际值: %d", len(leases))
+	}
+	if leases[0].State != LeaseReserved || leases[5].State != LeaseLeased || leases[5].Reservation != "scheduler" {
+		t.Errorf("预留 ID 的租约状态不正确: %+v, %+v", leases[0], leases[5])
+	}
+
+	// GetID 只会返回未预留的 ID
+	got := map[int64]bool{}
+	for {
+		workerID, _, err := gen.GetID()
+		if errors.Is(err, ErrNoAvailableID) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("GetID() 失败: %v", err)
+		}
+		got[workerID] = true
+	}
+	if len(got) != 3 || !got[4] || !got[6] || !got[7] {
+		t.Errorf("只有 WorkerID 4、6、7 可以被分配, 实际分配: %v", got)
+	}
+
+	// 释放后 ID 5 离开池
+	if err := gen.Release(5, token5[:22]); err != nil {
+		t.Fatalf("Release(5) 失败: %v", err)
+	}
+	stats, err := gen.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if stats.Total != 3 || stats.Reserved != 5 {
+		t.Errorf("池中应有 3 个 ID、5 个预留 ID, 实际值: %+v", stats)
+	}
+
+	// 扩容时不会把预留 ID 加回池中
+	if err := gen.Resize(ctx, 4, false); err != nil {
+		t.Fatalf("Resize() 失败: %v", err)
+	}
+	if n := client.ZCard(ctx, gen.getIDsKey()).Val(); n != 11 {
+		t.Errorf("扩容后池中应有 11 个 ID, 实际值: %d", n)
+	}
+
+	if _, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(3), WithStaticAssignment(8, "x")); err == nil {
+		t.Error("预留超出范围的 ID 应该返回错误")
+	}
+}
+
+func TestRedisGenerator_Unreserve(t *testing.T) {
+	for _, mode := range []PoolMode{PoolModeEager, PoolModeLazy, PoolModeBitmap} {
+		t.Run(string(mode), func(t *testing.T) {
+			client, cleanup := setupTestRedis(t)
+			defer cleanup()
+			ctx := context.Background()
+
+			gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithReservedRange(0, 2),
+				WithPoolMode(mode))
+			if err != nil {
+				t.Fatalf("创建 RedisGenerator 失败: %v", err)
+			}
+			if workerID, _, err := gen.GetID(); err != nil || workerID != 3 {
+				t.Fatalf("只有 WorkerID 3 未被预留, 实际值: %d, 错误: %v", workerID, err)
+			}
+			if _, _, err := gen.GetID(); !errors.Is(err, ErrNoAvailableID) {
+				t.Fatalf("池耗尽后应该返回 ErrNoAvailableID, 实际值: %v", err)
+			}
+
+			// 重置不会清除预留
+			if err := gen.Reset(ctx, true); err != nil {
+				t.Fatalf("Reset() 失败: %v", err)
+			}
+			if reservations, _ := gen.Reservations(ctx); len(reservations) != 3 {
+				t.Fatalf("Reset 后应该保留 3 个预留, 实际值: %v", reservations)
+			}
+			if _, _, err := gen.GetID(); err != nil {
+				t.Fatalf("GetID() 失败: %v", err)
+			}
+
+			// 取消预留后 ID 回到池中
+			if err := gen.Unreserve(ctx, 1, "", ""); err == nil {
+				t.Error("操作人为空时应该返回错误")
+			}
+			if err := gen.Unreserve(ctx, 1, "admin", "config changed"); err != nil {
+				t.Fatalf("Unreserve() 失败: %v", err)
+			}
+			if workerID, _, err := gen.GetID(); err != nil || workerID != 1 {
+				t.Errorf("取消预留后应该分配 WorkerID 1, 实际值: %d, 错误: %v", workerID, err)
+			}
+			if err := gen.Unreserve(ctx, 1, "admin", ""); !errors.Is(err, ErrNotReserved) {
+				t.Errorf("未被预留的 ID 应该返回 ErrNotReserved, 实际值: %v", err)
+			}
+
+			reservations, err := gen.Reservations(ctx)
+			if err != nil {
+				t.Fatalf("Reservations() 失败: %v", err)
+			}
+			if len(reservations) != 2 || reservations[0].WorkerID != 0 || reservations[1].WorkerID != 2 {
+				t.Errorf("剩余的预留应该为 0 和 2, 实际值: %v", reservations)
+			}
+			entries, err := gen.AuditLog(ctx, 1)
+			if err != nil {
+				t.Fatalf("AuditLog() 失败: %v", err)
+			}
+			if len(entries) != 1 || entries[0].Action != "unreserve" || entries[0].WorkerID != 1 ||
+				entries[0].Operator != "admin" || entries[0].Reason != "config changed" {
+				t.Errorf("审计记录不正确: %+v", entries)
+			}
+		})
+	}
+}
+
+func TestRedisGenerator_Reset(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithReservedRange(0, 0))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	// 模拟部分损坏的池
+	if err := client.ZRem(ctx, gen.getIDsKey(), "3").Err(); err != nil {
+		t.Fatalf("删除 ID 失败: %v", err)
+	}
+
+	if err := gen.Reset(ctx, false); !errors.Is(err, ErrWorkerIDInUse) {
+		t.Fatalf("存在有效租约时 Reset 应该返回 ErrWorkerIDInUse, 实际值: %v", err)
+	}
+	if err := gen.Renew(workerID, token); err != nil {
+		t.Errorf("Reset 被拒绝后租约应该保持有效: %v", err)
+	}
+
+	if err := gen.Reset(ctx, true); err != nil {
+		t.Fatalf("Reset(force) 失败: %v", err)
+	}
+	if err := gen.Renew(workerID, token); !errors.Is(err, ErrNotAssigned) {
+		t.Errorf("强制 Reset 后 Renew 应该返回 ErrNotAssigned, 实际值: %v", err)
+	}
+	stats, err := gen.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if stats.Total != 3 || stats.Free != 3 || stats.Reserved != 1 {
+		t.Errorf("重建后的池应有 3 个空闲 ID 和 1 个预留 ID, 实际值: %+v", stats)
+	}
+
+	// 没有有效租约时无需强制
+	if err := gen.Reset(ctx, false); err != nil {
+		t.Errorf("没有有效租约时 Reset 失败: %v", err)
+	}
+}
+
+func TestRedisGenerator_RebuildInBatches(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// eager 模式下 Reset 和 Resize 与初始化一样分批写入 ID，全部写入后才记录元数据
+	counter := &scriptCounter{counts: make(map[string]int)}
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(13), WithReservedRange(0, 1),
+		WithMetrics(counter))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	counter.counts = make(map[string]int)
+	if err := gen.Reset(ctx, false); err != nil {
+		t.Fatalf("Reset() 失败: %v", err)
+	}
+	if n := counter.counts["init"]; n != 8192/initBatchSize {
+		t.Errorf("Reset 应该分 %d 批写入 ID, 实际值: %d", 8192/initBatchSize, n)
+	}
+	if n := client.ZCard(ctx, gen.getIDsKey()).Val(); n != 8190 {
+		t.Errorf("Reset 后池中应有 8190 个 ID, 实际值: %d", n)
+	}
+	if meta := client.HGetAll(ctx, gen.getMetaKey()).Val(); meta["version"] != poolVersion || meta["max_worker_id"] != "8191" {
+		t.Errorf("Reset 后应该重新写入元数据, 实际值: %v", meta)
+	}
+
+	counter.counts = make(map[string]int)
+	if err := gen.Resize(ctx, 14, false); err != nil {
+		t.Fatalf("Resize() 失败: %v", err)
+	}
+	if n := counter.counts["init"]; n != 16384/initBatchSize {
+		t.Errorf("Resize 应该分 %d 批写入 ID, 实际值: %d", 16384/initBatchSize, n)
+	}
+	if n := client.ZCard(ctx, gen.getIDsKey()).Val(); n != 16382 {
+		t.Errorf("Resize 后池中应有 16382 个 ID, 实际值: %d", n)
+	}
+	if meta := client.HGetAll(ctx, gen.getMetaKey()).Val(); meta["version"] != poolVersion || meta["max_worker_id"] != "16383" {
+		t.Errorf("Resize 后应该重新写入元数据, 实际值: %v", meta)
+	}
+}
+
+func TestRedisGenerator_Destroy(t *testing.T) {
+	for _, mode := range []PoolMode{PoolModeEager, PoolModeLazy, PoolModeBitmap} {
+		t.Run(string(mode), func(t *testing.T) {
+			client, cleanup := setupTestRedis(t)
+			defer cleanup()
+			ctx := context.Background()
+
+			gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithReservedRange(0, 0),
+				WithPoolMode(mode))
+			if err != nil {
+				t.Fatalf("创建 RedisGenerator 失败: %v", err)
+			}
+			workerID, _, err := gen.GetID()
+			if err != nil {
+				t.Fatalf("GetID() 失败: %v", err)
+			}
+			if err := gen.Revoke(ctx, workerID, "test", ""); err != nil {
+				t.Fatalf("Revoke() 失败: %v", err)
+			}
+			if _, _, err := gen.GetID(); err != nil {
+				t.Fatalf("GetID() 失败: %v", err)
+			}
+
+			if err := gen.Destroy(ctx, false); !errors.Is(err, ErrWorkerIDInUse) {
+				t.Fatalf("存在有效租约时 Destroy 应该返回 ErrWorkerIDInUse, 实际值: %v", err)
+			}
+			if err := gen.Destroy(ctx, true); err != nil {
+				t.Fatalf("Destroy(force) 失败: %v", err)
+			}
+
+			keys, err := client.Keys(ctx, "{workerid:cluster:test-cluster}:*").Result()
+			if err != nil {
+				t.Fatalf("查询键失败: %v", err)
+			}
+			if len(keys) != 0 {
+				t.Errorf("Destroy 后不应存在任何键, 实际值: %v", keys)
+			}
+			if workerID, _, err := gen.GetID(); !errors.Is(err, ErrNoAvailableID) {
+				t.Errorf("Destroy 后 GetID 应该返回 ErrNoAvailableID, 实际值: %d, %v", workerID, err)
+			}
+		})
+	}
+}
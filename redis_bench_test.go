@@ -0,0 +1,79 @@
+package workerid
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var benchPoolModes = []PoolMode{PoolModeEager, PoolModeLazy, PoolModeBitmap}
+
+var benchWorkerBits = []uint{10, 16, 20}
+
+// setupBenchRedis 同 setupTestRedis，取消读超时，eager 模式下 20 位的池单次分配 ID 的脚本耗时较长
+func setupBenchRedis(b *testing.B) (*redis.Client, func()) {
+	client, cleanup := setupTestRedis(b)
+	opts := *client.Options()
+	opts.ReadTimeout = -1
+	benchClient := redis.NewClient(&opts)
+	return benchClient, func() {
+		benchClient.Close()
+		cleanup()
+	}
+}
+
+// BenchmarkNewRedisGenerator 比较不同存储方式下初始化池的耗时
+func BenchmarkNewRedisGenerator(b *testing.B) {
+	for _, mode := range benchPoolModes {
+		for _, bits := range benchWorkerBits {
+			b.Run(fmt.Sprintf("%s/%dbits", mode, bits), func(b *testing.B) {
+				client, cleanup := setupBenchRedis(b)
+				defer cleanup()
+
+				for i := 0; i < b.N; i++ {
+					_, err := NewRedisGenerator(client, fmt.Sprintf("bench-%d", i),
+						WithWorkerBits(bits), WithPoolMode(mode))
+					if err != nil {
+						b.Fatalf("创建 RedisGenerator 失败: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkRedisGenerator_GetID 比较不同存储方式下分配和释放 ID 的耗时，池中已有 512 个 ID 在用，
+// 10 位的池中为一半，更大的池中占比更低
+func BenchmarkRedisGenerator_GetID(b *testing.B) {
+	for _, mode := range benchPoolModes {
+		for _, bits := range benchWorkerBits {
+			b.Run(fmt.Sprintf("%s/%dbits", mode, bits), func(b *testing.B) {
+				client, cleanup := setupBenchRedis(b)
+				defer cleanup()
+
+				gen, err := NewRedisGenerator(client, "bench", WithWorkerBits(bits), WithPoolMode(mode))
+				if err != nil {
+					b.Fatalf("创建 RedisGenerator 失败: %v", err)
+				}
+				// 占用固定数量的 ID，避免只测量空池的情况，按比例占用时 20 位的池准备阶段耗时过长
+				for i := 0; i < 512; i++ {
+					if _, _, err := gen.GetID(); err != nil {
+						b.Fatalf("GetID() 失败: %v", err)
+					}
+				}
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					workerID, token, err := gen.GetID()
+					if err != nil {
+						b.Fatalf("GetID() 失败: %v", err)
+					}
+					if err := gen.Release(workerID, token); err != nil {
+						b.Fatalf("Release() 失败: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
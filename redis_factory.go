@@ -0,0 +1,104 @@
+package workerid
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Factory 在同一个 Redis 客户端上为多个租户和集群创建并缓存 RedisGenerator。
+// 每个租户使用独立的键前缀 "<prefix>:<tenant>"，不同租户的同名集群互不影响
+type Factory struct {
+	client  *redis.Client
+	options []Option
+	prefix  string
+
+	mu         sync.Mutex
+	generators map[tenantCluster]*RedisGenerator
+}
+
+type tenantCluster struct {
+	tenant  string
+	cluster string
+}
+
+// NewFactory 创建 Factory，options 会应用到每个 RedisGenerator，
+// 其中 WithKeyPrefix 设置的前缀作为所有租户键前缀的公共部分
+func NewFactory(client *redis.Client, options ...Option) (*Factory, error) {
+	opts := &generatorOptions{keyPrefix: defaultKeyPrefix}
+	for _, o := range options {
+		o(opts)
+	}
+	if err := validateKeyPrefix(opts.keyPrefix); err != nil {
+		return nil, err
+	}
+
+	return &Factory{
+		client:     client,
+		options:    options,
+		prefix:     opts.keyPrefix,
+		generators: make(map[tenantCluster]*RedisGenerator),
+	}, nil
+}
+
+// Generator 返回租户 tenant 下集群 cluster 的 RedisGenerator，首次调用时使用 options 创建，之后返回缓存的实例。
+// 租户名称不能包含 ":" 或为 "cluster"，否则不同租户和集群的组合可能映射到相同的键。
+// 指标的集群标签和日志带有租户的键前缀，不同租户的同名集群分别统计
+func (f *Factory) Generator(tenant, cluster string, options ...Option) (*RedisGenerator, error) {
+	if tenant == "" {
+		return nil, errors.New("tenant is empty")
+	}
+	if strings.Contains(tenant, ":") {
+		return nil, fmt.Errorf("tenant %q must not contain ':'", tenant)
+	}
+	// 租户 cluster 的键 {<prefix>:cluster:cluster:<name>} 与基础前缀下名为 cluster:<name> 的集群相同
+	if tenant == "cluster" {
+		return nil, errors.New(`tenant must not be "cluster"`)
+	}
+	key := tenantCluster{tenant: tenant, cluster: cluster}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if g, ok := f.generators[key]; ok {
+		return g, nil
+	}
+
+	all := make([]Option, 0, len(f.options)+len(options)+1)
+	all = append(all, f.options...)
+	all = append(all, options...)
+	all = append(all, WithKeyPrefix(f.prefix+":"+tenant))
+	g, err := NewRedisGenerator(f.client, cluster, all...)
+	if err != nil {
+		return nil, err
+	}
+	f.generators[key] = g
+	return g, nil
+}
+
+// Remove 移除缓存的 RedisGenerator，不会修改 Redis 中的数据
+func (f *Factory) Remove(tenant, cluster string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.generators, tenantCluster{tenant: tenant, cluster: cluster})
+}
+
+// Tenants 返回已创建过 RedisGenerator 的租户，按名称升序排列
+func (f *Factory) Tenants() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	seen := make(map[string]bool)
+	tenants := make([]string, 0, len(f.generators))
+	for key := range f.generators {
+		if !seen[key.tenant] {
+			seen[key.tenant] = true
+			tenants = append(tenants, key.tenant)
+		}
+	}
+	sort.Strings(tenants)
+	return tenants
+}
@@ -0,0 +1,173 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestWithKeyPrefix(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// 两个环境共用同一个 Redis 和集群名称
+	staging, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(1), WithKeyPrefix("staging"))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	prod, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(1), WithKeyPrefix("prod"))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	if key := staging.getIDsKey(); key != "{staging:cluster:test-cluster}:ids" {
+		t.Errorf("键格式不正确: %s", key)
+	}
+
+	// 耗尽 staging 的池不影响 prod
+	for i := 0; i < 2; i++ {
+		if _, _, err := staging.GetID(); err != nil {
+			t.Fatalf("GetID() 失败: %v", err)
+		}
+	}
+	if _, _, err := staging.GetID(); !errors.Is(err, ErrNoAvailableID) {
+		t.Errorf("staging 的池耗尽后应该返回 ErrNoAvailableID, 实际值: %v", err)
+	}
+	workerID, token, err := prod.GetID()
+	if err != nil {
+		t.Fatalf("prod GetID() 失败: %v", err)
+	}
+	if err := staging.Renew(workerID, token); !errors.Is(err, ErrTokenMismatch) {
+		t.Errorf("使用 prod 的 Token 续期 staging 的 ID 应该返回 ErrTokenMismatch, 实际值: %v", err)
+	}
+
+	stats, err := prod.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if stats.Leased != 1 {
+		t.Errorf("prod 应有 1 个已分配的 ID, 实际值: %d", stats.Leased)
+	}
+
+	for _, prefix := range []string{"", "a{b", "a}"} {
+		if _, err := NewRedisGenerator(client, "test-cluster", WithKeyPrefix(prefix)); err == nil {
+			t.Errorf("前缀 %q 应该返回错误", prefix)
+		}
+	}
+}
+
+func TestFactory(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	factory, err := NewFactory(client, WithWorkerBits(2), WithKeyPrefix("platform"))
+	if err != nil {
+		t.Fatalf("NewFactory() 失败: %v", err)
+	}
+
+	acme, err := factory.Generator("acme", "orders")
+	if err != nil {
+		t.Fatalf("Generator() 失败: %v", err)
+	}
+	if again, _ := factory.Generator("acme", "orders"); again != acme {
+		t.Error("同一租户和集群应该返回缓存的实例")
+	}
+	if key := acme.getIDsKey(); key != "{platform:acme:cluster:orders}:ids" {
+		t.Errorf("键格式不正确: %s", key)
+	}
+
+	// 每个租户的选项可以单独覆盖
+	globex, err := factory.Generator("globex", "orders", WithWorkerBits(1))
+	if err != nil {
+		t.Fatalf("Generator() 失败: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, _, err := acme.GetID(); err != nil {
+			t.Fatalf("acme GetID() 失败: %v", err)
+		}
+	}
+	stats, err := globex.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if stats.Total != 2 || stats.Leased != 0 {
+		t.Errorf("globex 的池不应受 acme 影响, 实际值: %+v", stats)
+	}
+
+	if tenants := factory.Tenants(); len(tenants) != 2 || tenants[0] != "acme" || tenants[1] != "globex" {
+		t.Errorf("租户列表不正确: %v", tenants)
+	}
+	factory.Remove("globex", "orders")
+	if tenants := factory.Tenants(); len(tenants) != 1 {
+		t.Errorf("移除后租户列表不正确: %v", tenants)
+	}
+
+	if _, err := factory.Generator("", "orders"); err == nil {
+		t.Error("租户为空时应该返回错误")
+	}
+
+	// 租户名称包含 ":" 时会与其他租户的集群冲突：a:cluster:b 的集群 c 和 a 的集群 b:cluster:c 使用相同的键
+	if _, err := factory.Generator("a:cluster:b", "c"); err == nil {
+		t.Error("租户名称包含 ':' 时应该返回错误")
+	}
+	other, err := factory.Generator("a", "b:cluster:c")
+	if err != nil {
+		t.Fatalf("Generator() 失败: %v", err)
+	}
+	if key := other.getIDsKey(); key != "{platform:a:cluster:b:cluster:c}:ids" {
+		t.Errorf("键格式不正确: %s", key)
+	}
+
+	// 租户 cluster 的集群 x 与基础前缀下的集群 cluster:x 使用相同的键
+	if _, err := factory.Generator("cluster", "x"); err == nil {
+		t.Error("租户名称为 cluster 时应该返回错误")
+	}
+}
+
+// usageRecorder 按集群标签记录最近一次上报的已分配 ID 数量
+type usageRecorder struct {
+	nopMetrics
+	mu     sync.Mutex
+	leased map[string]int64
+}
+
+func (r *usageRecorder) PoolUsage(cluster string, leased, _ int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.leased[cluster] = leased
+}
+
+func TestFactory_Metrics(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	recorder := &usageRecorder{leased: make(map[string]int64)}
+	factory, err := NewFactory(client, WithWorkerBits(2), WithKeyPrefix("platform"), WithMetrics(recorder))
+	if err != nil {
+		t.Fatalf("NewFactory() 失败: %v", err)
+	}
+	acme, err := factory.Generator("acme", "orders")
+	if err != nil {
+		t.Fatalf("Generator() 失败: %v", err)
+	}
+	globex, err := factory.Generator("globex", "orders")
+	if err != nil {
+		t.Fatalf("Generator() 失败: %v", err)
+	}
+	for _, gen := range []*RedisGenerator{acme, acme, globex} {
+		if _, _, err := gen.GetID(); err != nil {
+			t.Fatalf("GetID() 失败: %v", err)
+		}
+	}
+
+	// 不同租户的同名集群分别统计
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if recorder.leased["platform:acme:orders"] != 2 || recorder.leased["platform:globex:orders"] != 1 {
+		t.Errorf("指标应该按租户区分集群, 实际值: %v", recorder.leased)
+	}
+}
@@ -2,15 +2,29 @@ package workerid
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
+	"log/slog"
+	"math/rand/v2"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
+// defaultKeyPrefix Redis 键的默认前缀
+const defaultKeyPrefix = "workerid"
+
+// validateKeyPrefix 检查键前缀，前缀位于 hash tag 内，不能为空或包含花括号
+func validateKeyPrefix(prefix string) error {
+	if prefix == "" || strings.ContainsAny(prefix, "{}") {
+		return fmt.Errorf("invalid key prefix %q", prefix)
+	}
+	return nil
+}
+
 type RedisGenerator struct {
 	cluster      string
 	maxWorkerID  uint32
@@ -20,16 +34,57 @@ type RedisGenerator struct {
 	clockSync    bool
 	lockKey      string
 	lockVal      string
+	holder       *Holder
+	metrics      Metrics
+	// metricsLabel 指标中的集群标签，使用自定义键前缀时带有前缀，避免不同前缀下的同名集群混在一起
+	metricsLabel    string
+	logger          *slog.Logger
+	hooks           *Hooks
+	capacityWarning float64
+	reuseDelay      int64
+	strategy        AllocationStrategy
+	reserved        map[uint32]string
+	pool            string
+	keyPrefix       string
+	poolMode        PoolMode
+	retryPolicy     RetryPolicy
+	breaker         *breaker
+	degraded        *degraded
+	leaseFile       string
+	resumePending   atomic.Bool
+	datacenterID    int64
+	datacenterBits  uint
+	machineBits     uint
 }
 
-var _ Generator = (*RedisGenerator)(nil)
+var _ ContextGenerator = (*RedisGenerator)(nil)
 
-// NewRedisGenerator 创建 RedisGenerator 实例
+// NewRedisGenerator 创建 RedisGenerator 实例，池不存在时初始化池
 func NewRedisGenerator(redisClient *redis.Client, cluster string, options ...Option) (*RedisGenerator, error) {
+	allocator, err := newRedisGenerator(redisClient, cluster, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := allocator.initAvailableIDs(); err != nil {
+		if allocator.degraded == nil || !isUnavailable(err) {
+			return nil, fmt.Errorf("initialize available IDs failed: %w", err)
+		}
+		// 降级模式下 Redis 不可用时推迟到 Redis 恢复后再初始化
+		allocator.logger.Warn("redis unavailable, pool initialization deferred", slog.Any("error", err))
+		allocator.degraded.initPending = true
+	}
+
+	return allocator, nil
+}
+
+// newRedisGenerator 校验选项并创建 RedisGenerator，不访问 Redis
+func newRedisGenerator(redisClient *redis.Client, cluster string, options ...Option) (*RedisGenerator, error) {
 	opts := &generatorOptions{
 		cluster:      cluster,
 		maxWorkerID:  511, // 默认 512 个 WorkerID，最大 WorkerID 为 511
 		maxLeaseTime: 5 * time.Minute,
+		keyPrefix:    defaultKeyPrefix,
 	}
 	for _, o := range options {
 		o(opts)
@@ -37,33 +92,129 @@ func NewRedisGenerator(redisClient *redis.Client, cluster string, options ...Opt
 	if opts.cluster == "" {
 		return nil, errors.New("cluster is empty")
 	}
+	if err := validateKeyPrefix(opts.keyPrefix); err != nil {
+		return nil, err
+	}
 	if opts.maxLeaseTime <= 0 {
 		opts.maxLeaseTime = 5 * time.Minute
 	}
+	// Redis 中的租约以秒为单位，不足 1 秒的租约会在分配时立即过期
+	if opts.maxLeaseTime < time.Second {
+		return nil, fmt.Errorf("max lease time %v is shorter than 1 second", opts.maxLeaseTime)
+	}
 	if opts.maxWorkerID <= 0 {
 		opts.maxWorkerID = 511
 	}
+	switch opts.strategy {
+	case "":
+		opts.strategy = AllocateLowest
+	case AllocateLowest, AllocateLeastRecentlyUsed, AllocateRandom:
+	default:
+		return nil, fmt.Errorf("unknown allocation strategy: %s", opts.strategy)
+	}
+	switch opts.poolMode {
+	case "":
+		opts.poolMode = PoolModeEager
+	case PoolModeEager, PoolModeLazy, PoolModeBitmap:
+	default:
+		return nil, fmt.Errorf("unknown pool mode: %s", opts.poolMode)
+	}
+	pool := opts.cluster
+	if opts.datacenterBits > 0 {
+		if opts.machineBits == 0 || opts.datacenterBits+opts.machineBits > 31 {
+			return nil, fmt.Errorf("invalid datacenter bits %d and machine bits %d", opts.datacenterBits, opts.machineBits)
+		}
+		if opts.datacenterID >= 1<<opts.datacenterBits {
+			return nil, fmt.Errorf("datacenter ID %d exceeds %d bits", opts.datacenterID, opts.datacenterBits)
+		}
+		pool = fmt.Sprintf("%s:dc:%d", opts.cluster, opts.datacenterID)
+	}
+	for id := range opts.reserved {
+		if id > opts.maxWorkerID {
+			return nil, fmt.Errorf("reserved worker ID %d exceeds max worker ID %d", id, opts.maxWorkerID)
+		}
+	}
 
 	allocator := &RedisGenerator{
-		cluster:      opts.cluster,
-		maxWorkerID:  opts.maxWorkerID,
-		leaseSeconds: int(opts.maxLeaseTime.Seconds()),
-		redisClient:  redisClient,
-		ctx:          context.Background(),
-		lockKey:      fmt.Sprintf("{workerid:cluster:%s}:lock", opts.cluster),
-		lockVal:      generateToken(),
+		cluster:         opts.cluster,
+		maxWorkerID:     opts.maxWorkerID,
+		leaseSeconds:    int(opts.maxLeaseTime.Seconds()),
+		redisClient:     redisClient,
+		ctx:             context.Background(),
+		lockKey:         fmt.Sprintf("{%s:cluster:%s}:lock", opts.keyPrefix, pool),
+		lockVal:         generateToken(),
+		holder:          opts.holder,
+		metrics:         opts.metrics,
+		logger:          newLogger(opts.logger, opts.cluster),
+		hooks:           opts.hooks,
+		capacityWarning: opts.capacityWarning,
+		reuseDelay:      max(int64(opts.reuseDelay.Seconds()), 0),
+		strategy:        opts.strategy,
+		reserved:        opts.reserved,
+		pool:            pool,
+		keyPrefix:       opts.keyPrefix,
+		poolMode:        opts.poolMode,
+		retryPolicy:     opts.retryPolicy,
+		leaseFile:       opts.leaseFile,
+		datacenterID:    int64(opts.datacenterID),
+		datacenterBits:  opts.datacenterBits,
+		machineBits:     opts.machineBits,
+	}
+	if allocator.metrics == nil {
+		allocator.metrics = nopMetrics{}
+	}
+	allocator.metricsLabel = opts.cluster
+	if opts.keyPrefix != defaultKeyPrefix {
+		allocator.metricsLabel = opts.keyPrefix + ":" + opts.cluster
+		allocator.logger = allocator.logger.With(slog.String("key_prefix", opts.keyPrefix))
+	}
+	allocator.resumePending.Store(opts.leaseFile != "")
+	if mode := opts.degradedMode; mode != nil {
+		if mode.FailureThreshold <= 0 {
+			mode.FailureThreshold = 3
+		}
+		if mode.Cooldown <= 0 {
+			mode.Cooldown = 30 * time.Second
+		}
+		allocator.breaker = &breaker{threshold: mode.FailureThreshold, cooldown: mode.Cooldown}
+		allocator.degraded = &degraded{DegradedMode: *mode, unverified: make(map[int64]string)}
 	}
+	return allocator, nil
+}
 
-	if err := allocator.initAvailableIDs(); err != nil {
-		return nil, fmt.Errorf("initialize available IDs failed: %w", err)
+// Cluster 返回集群名称
+func (g *RedisGenerator) Cluster() string {
+	return g.cluster
+}
+
+// composeID 将池中的机器 ID 组合为返回给调用方的 WorkerID，未设置 WithDatacenter 时原样返回
+func (g *RedisGenerator) composeID(machineID int64) int64 {
+	if g.datacenterBits == 0 {
+		return machineID
 	}
+	return g.datacenterID<<g.machineBits | machineID
+}
 
-	return allocator, nil
+// machineID 将调用方传入的 WorkerID 还原为池中的机器 ID，超出范围或数据中心不匹配时返回 ErrInvalidWorkerID
+func (g *RedisGenerator) machineID(workerID int64) (int64, error) {
+	if workerID < 0 {
+		return 0, ErrInvalidWorkerID
+	}
+	if g.datacenterBits > 0 {
+		if workerID>>g.machineBits != g.datacenterID {
+			return 0, ErrInvalidWorkerID
+		}
+		workerID &= 1<<g.machineBits - 1
+	}
+	if workerID > int64(g.maxWorkerID) {
+		return 0, ErrInvalidWorkerID
+	}
+	return workerID, nil
 }
 
-func (g *RedisGenerator) getCurrentTime() (int64, error) {
+func (g *RedisGenerator) getCurrentTime(ctx context.Context) (int64, error) {
 	if g.clockSync {
-		t, err := g.redisClient.Time(g.ctx).Result()
+		t, err := g.redisClient.Time(ctx).Result()
 		if err != nil {
 			return 0, err
 		}
@@ -72,31 +223,221 @@ func (g *RedisGenerator) getCurrentTime() (int64, error) {
 	return time.Now().Unix(), nil
 }
 
+// poolVersion 池数据结构的版本，初始化完成后写入元数据
+const poolVersion = "1"
+
+var initScript = redis.NewScript(`
+	local key = KEYS[1]
+	local metaKey = KEYS[2]
+	local reservedKey = KEYS[3]
+	local maxID = tonumber(ARGV[2])
+	local mode = ARGV[3]
+
+	-- 同一个池只能使用一种存储方式，没有记录存储方式的已有池由旧版本初始化，为 eager
+	local meta = redis.call('HMGET', metaKey, 'version', 'max_worker_id', 'mode')
+	local existing = meta[3]
+	if not existing and (meta[1] or redis.call('EXISTS', key) == 1) then
+		existing = 'eager'
+	end
+	if existing and existing ~= mode then
+		return {err="Pool mode mismatch"}
+	end
+
+	-- 元数据记录的范围以池为准，只能通过 Resize 修改，避免使用更多位数启动的实例扩大共享的池
+	if meta[2] and tonumber(meta[2]) ~= maxID then
+		return {err="Pool size mismatch " .. meta[2]}
+	end
+
+	-- 元数据记录的版本一致时，说明池已完整初始化
+	if meta[1] == ARGV[1] and meta[2] then
+		return -1
+	end
+
+	-- lazy 和 bitmap 模式下 ID 在首次分配时才写入池中
+	if mode ~= 'eager' then
+		redis.call('HSET', metaKey, 'version', ARGV[1], 'max_worker_id', maxID, 'mode', mode)
+		return 0
+	end
+
+	-- 补齐 [from, to] 范围内缺失的 ID（跳过预留的 ID），NX 保证不会重置已分配 ID 的过期时间，同时修复不完整的池。
+	-- 分批执行避免长时间阻塞 Redis，最后一批完成后才写入元数据，中途失败时下次启动会重新补齐
+	local added = 0
+	local to = tonumber(ARGV[5])
+	for i = tonumber(ARGV[4]), to do
+		if redis.call('HEXISTS', reservedKey, i) == 0 then
+			added = added + redis.call('ZADD', key, 'NX', 0, i)
+		end
+	end
+	if to >= maxID then
+		redis.call('HSET', metaKey, 'version', ARGV[1], 'max_worker_id', maxID, 'mode', mode)
+	end
+	return added
+`)
+
+// initBatchSize eager 模式下每次执行初始化脚本写入的 ID 数量上限
+const initBatchSize = 4096
+
 func (g *RedisGenerator) initAvailableIDs() error {
-	key := g.getIDsKey()
-	// 如果 key 已经存在，则直接返回
-	if n, err := g.redisClient.ZCard(g.ctx, key).Result(); err == nil && n > 0 {
+	// 先写入预留 ID，初始化时跳过它们
+	if err := g.reserveIDs(); err != nil {
+		return err
+	}
+
+	added, err := g.fillPool(g.ctx, g.maxWorkerID)
+	if err != nil {
+		g.logger.Error("initialize worker ID pool failed", slog.Any("error", err))
+		return err
+	}
+	// 池已初始化（可能由并发启动的其他实例完成）
+	if added < 0 {
+		g.logger.Debug("worker ID pool already initialized")
 		return nil
 	}
-	pipe := g.redisClient.Pipeline()
-	for i := 0; i <= int(g.maxWorkerID); i++ {
-		pipe.ZAdd(g.ctx, key, &redis.Z{
-			Score:  0,
-			Member: strconv.Itoa(i),
+	g.logger.Info("worker ID pool initialized", slog.Uint64("max_worker_id", uint64(g.maxWorkerID)),
+		slog.String("mode", string(g.poolMode)), slog.Int64("added", added))
+	return nil
+}
+
+// fillPool 分批执行初始化脚本，eager 模式下写入 [0, maxID] 中缺失且未预留的 ID，最后一批写入元数据。
+// 返回写入的 ID 数量，池已初始化时返回 -1
+func (g *RedisGenerator) fillPool(ctx context.Context, maxID uint32) (int64, error) {
+	var added int64
+	keys := []string{g.getIDsKey(), g.getMetaKey(), g.getReservedKey()}
+	for from := int64(0); from <= int64(maxID); from += initBatchSize {
+		to := min(from+initBatchSize-1, int64(maxID))
+		var n int64
+		err := g.retry(ctx, "init", func(int) (err error) {
+			n, err = g.runScript(ctx, initScript, "init", keys,
+				poolVersion, maxID, string(g.poolMode), from, to).Int64()
+			switch {
+			case err == nil:
+			case strings.HasPrefix(err.Error(), "Pool size mismatch "):
+				return fmt.Errorf("%w: max worker ID is %s but %d is configured, use Resize to change it",
+					ErrPoolMismatch, strings.TrimPrefix(err.Error(), "Pool size mismatch "), maxID)
+			case err.Error() == "Pool mode mismatch":
+				return fmt.Errorf("%w: pool was not created in %s mode", ErrPoolMismatch, g.poolMode)
+			}
+			return err
 		})
+		if err != nil {
+			return 0, err
+		}
+		// 其他实例已完成初始化
+		if n < 0 {
+			if from == 0 {
+				return -1, nil
+			}
+			break
+		}
+		added += n
+		if g.poolMode != PoolModeEager {
+			break
+		}
 	}
-	_, err := pipe.Exec(g.ctx)
-	return err
+	return added, nil
+}
+
+var reserveScript = redis.NewScript(`
+	local key = KEYS[1]
+	local reservedKey = KEYS[2]
+	local now = tonumber(ARGV[1])
+	local bitmap = ARGV[2] == 'bitmap'
+
+	-- 记录预留的 ID 并将其移出池，仍在租约期内的 ID 保留到释放或过期后再移出
+	-- bitmap 模式下同时将预留 ID 的位标记为在用
+	local leased = 0
+	for i = 3, #ARGV, 2 do
+		local workerID = ARGV[i]
+		redis.call('HSET', reservedKey, workerID, ARGV[i+1])
+		if bitmap then
+			redis.call('SETBIT', KEYS[3], workerID, 1)
+		end
+		local score = redis.call('ZSCORE', key, workerID)
+		if score and tonumber(score) > now then
+			leased = leased + 1
+		else
+			redis.call('ZREM', key, workerID)
+		end
+	end
+	return leased
+`)
+
+// reserveIDs 将 WithReservedRange 和 WithStaticAssignment 设置的预留 ID 写入 Redis
+func (g *RedisGenerator) reserveIDs() error {
+	if len(g.reserved) == 0 {
+		return nil
+	}
+	keys := []string{g.getIDsKey(), g.getReservedKey(), g.getBitmapKey()}
+	var leased int64
+	err := g.retry(g.ctx, "reserve", func(int) error {
+		now, err := g.getCurrentTime(g.ctx)
+		if err != nil {
+			return fmt.Errorf("get current time failed: %w", err)
+		}
+
+		args := make([]any, 0, len(g.reserved)*2+2)
+		args = append(args, now, string(g.poolMode))
+		for id, name := range g.reserved {
+			args = append(args, id, name)
+		}
+		leased, err = g.runScript(g.ctx, reserveScript, "reserve", keys, args...).Int64()
+		return err
+	})
+	if err != nil {
+		g.logger.Error("reserve worker IDs failed", slog.Any("error", err))
+		return err
+	}
+	if leased > 0 {
+		g.logger.Warn("reserved worker IDs are still leased and will leave the pool once released",
+			slog.Int64("count", leased))
+	}
+	g.logger.Debug("worker IDs reserved", slog.Int("count", len(g.reserved)))
+	return nil
 }
 
 // getIDsKey 获取存储 WorkerID 的 Sorted Set 键
 func (g *RedisGenerator) getIDsKey() string {
-	return fmt.Sprintf("{workerid:cluster:%s}:ids", g.cluster)
+	return fmt.Sprintf("{%s:cluster:%s}:ids", g.keyPrefix, g.pool)
 }
 
 // getTokenKey 获取 Token 存储键
 func (g *RedisGenerator) getTokenKey() string {
-	return fmt.Sprintf("{workerid:cluster:%s}:tokens", g.cluster)
+	return fmt.Sprintf("{%s:cluster:%s}:tokens", g.keyPrefix, g.pool)
+}
+
+// getAuditKey 获取管理操作审计日志存储键
+func (g *RedisGenerator) getAuditKey() string {
+	return fmt.Sprintf("{%s:cluster:%s}:audit", g.keyPrefix, g.pool)
+}
+
+// getEventChannel 获取池变更事件的发布频道
+func (g *RedisGenerator) getEventChannel() string {
+	return fmt.Sprintf("{%s:cluster:%s}:events", g.keyPrefix, g.pool)
+}
+
+// getReservedKey 获取预留 ID 存储键，值为静态分配的持有者名称
+func (g *RedisGenerator) getReservedKey() string {
+	return fmt.Sprintf("{%s:cluster:%s}:reserved", g.keyPrefix, g.pool)
+}
+
+// getMetaKey 获取池元数据存储键，记录初始化版本和 ID 范围
+func (g *RedisGenerator) getMetaKey() string {
+	return fmt.Sprintf("{%s:cluster:%s}:meta", g.keyPrefix, g.pool)
+}
+
+// getCursorKey 获取 PoolModeLazy 下一个从未分配过的 ID 的游标存储键
+func (g *RedisGenerator) getCursorKey() string {
+	return fmt.Sprintf("{%s:cluster:%s}:next", g.keyPrefix, g.pool)
+}
+
+// getBitmapKey 获取 PoolModeBitmap 在用 ID 位图的存储键
+func (g *RedisGenerator) getBitmapKey() string {
+	return fmt.Sprintf("{%s:cluster:%s}:bitmap", g.keyPrefix, g.pool)
+}
+
+// getHolderKey 获取持有者信息存储键
+func (g *RedisGenerator) getHolderKey() string {
+	return fmt.Sprintf("{%s:cluster:%s}:holders", g.keyPrefix, g.pool)
 }
 
 var getIDScript = redis.NewScript(`
@@ -104,9 +445,114 @@ var getIDScript = redis.NewScript(`
 	local now = tonumber(ARGV[1])
 	local lease = tonumber(ARGV[2])
 
-	-- 查找最小可用 ID
-	local ids = redis.call('ZRANGEBYSCORE', key, '-inf', now, 'WITHSCORES', 'LIMIT', 0, 1)
-	if #ids == 0 then return nil end
+	-- 分数不大于 cutoff 的 ID 已过隔离期，可以被重新分配
+	local cutoff = tonumber(ARGV[5])
+
+	-- 按分配策略查找可用 ID，没有可用 ID 时返回 -1 和当前的使用量
+	-- lowest 和 lru 都选择分数最小的 ID，lru 在释放时记录释放时间，因此会选中空闲最久的 ID
+	-- 预留前已分配的 ID 在释放前仍留在池中，被选中时将其移出池并重新选择
+	-- lazy 模式下池中只有分配过的 ID，从未分配过的 ID 由游标按顺序发放
+	-- bitmap 模式下池中只有在用和释放后仍在隔离期或记录了释放时间的 ID，其余 ID 通过位图查找
+	-- 池被 Destroy 后没有元数据，不再发放从未分配过的 ID
+	local initialized = redis.call('EXISTS', KEYS[8]) == 1
+	local lazy = ARGV[8] == 'lazy' and initialized
+	local bitmap = ARGV[8] == 'bitmap' and initialized
+	local maxID = tonumber(ARGV[9])
+	local strategy = ARGV[6]
+
+	-- 发放一个从未分配过（bitmap 模式下为未标记）的 ID，没有时返回空表
+	local function fresh()
+		if lazy then
+			local next = tonumber(redis.call('GET', KEYS[6]) or '0')
+			local found = {}
+			while next <= maxID do
+				local candidate = tostring(next)
+				next = next + 1
+				if redis.call('HEXISTS', KEYS[5], candidate) == 0 and not redis.call('ZSCORE', key, candidate) then
+					found = {candidate}
+					break
+				end
+			end
+			redis.call('SET', KEYS[6], next)
+			return found
+		elseif bitmap then
+			-- random 从随机位置开始查找，其余策略查找最小的空闲 ID
+			local pos = -1
+			if strategy == 'random' then
+				pos = tonumber(ARGV[7]) % (maxID + 1)
+				if redis.call('GETBIT', KEYS[7], pos) == 1 then
+					pos = redis.call('BITPOS', KEYS[7], 0, math.floor(pos / 8))
+				end
+			end
+			if pos < 0 or pos > maxID then
+				pos = redis.call('BITPOS', KEYS[7], 0)
+			end
+			if pos >= 0 and pos <= maxID then
+				redis.call('SETBIT', KEYS[7], pos, 1)
+				return {tostring(pos)}
+			end
+		end
+		return {}
+	end
+
+	-- 从未分配过的 ID 的数量，lazy 模式下为游标之后未预留的 ID，bitmap 模式下为未标记的 ID
+	local function unused()
+		if lazy then
+			local next = tonumber(redis.call('GET', KEYS[6]) or '0')
+			if next > maxID then
+				return 0
+			end
+			local n = maxID - next + 1
+			for _, reserved in ipairs(redis.call('HKEYS', KEYS[5])) do
+				if tonumber(reserved) >= next and tonumber(reserved) <= maxID then
+					n = n - 1
+				end
+			end
+			return n
+		elseif bitmap then
+			return maxID + 1 - redis.call('BITCOUNT', KEYS[7])
+		end
+		return 0
+	end
+
+	-- lowest 优先重用池中的 ID；从未分配过的 ID 空闲最久，lru 优先发放它们；
+	-- random 按数量在池中可重用的 ID 和从未分配过的 ID 之间随机选择
+	local ids = {}
+	if strategy == 'lru' then
+		ids = fresh()
+	elseif strategy == 'random' and (lazy or bitmap) then
+		local free = redis.call('ZCOUNT', key, '-inf', cutoff)
+		local n = unused()
+		if n > 0 and tonumber(ARGV[7]) % (free + n) >= free then
+			ids = fresh()
+		end
+	end
+	if #ids == 0 then
+		repeat
+			local offset = 0
+			if strategy == 'random' then
+				local free = redis.call('ZCOUNT', key, '-inf', cutoff)
+				if free > 0 then
+					offset = tonumber(ARGV[7]) % free
+				end
+			end
+			ids = redis.call('ZRANGEBYSCORE', key, '-inf', cutoff, 'WITHSCORES', 'LIMIT', offset, 1)
+			if #ids > 0 and redis.call('HEXISTS', KEYS[5], ids[1]) == 1 then
+				redis.call('ZREM', key, ids[1])
+				ids = nil
+			end
+		until ids
+	end
+	if #ids == 0 then
+		ids = fresh()
+	end
+
+	-- lazy 和 bitmap 模式下池的容量还包括从未分配过的 ID
+	local total = redis.call('ZCARD', key) + unused()
+	if #ids == 0 then
+		local leased = redis.call('ZCOUNT', key, '(' .. now, '+inf')
+		return {-1, leased, redis.call('ZCOUNT', key, '(' .. cutoff, '+inf') - leased, total}
+	end
 
 	local workerID = ids[1]
 	local newExpire = now + lease
@@ -121,21 +567,118 @@ var getIDScript = redis.NewScript(`
 	redis.call('HSET', tokenKey, workerID, tokenData)
 	redis.call('EXPIRE', tokenKey, lease * 3)  -- 设置 Token 过期时间
 
-	return workerID
+	-- 存储持有者信息，未提供时清除上一个持有者留下的记录
+	local holderKey = KEYS[3]
+	local holder = ARGV[4]
+	if holder ~= '' then
+		redis.call('HSET', holderKey, workerID, holder)
+		redis.call('EXPIRE', holderKey, lease * 3)
+	else
+		redis.call('HDEL', holderKey, workerID)
+	end
+
+	redis.call('PUBLISH', KEYS[4], cjson.encode({type='acquired', worker_id=tonumber(workerID), time=now}))
+
+	-- 统计租约有效（包含本次分配的 ID）和处于隔离期的 ID 数量，用于容量预警
+	local leased = redis.call('ZCOUNT', key, '(' .. now, '+inf')
+	local quarantined = redis.call('ZCOUNT', key, '(' .. cutoff, '+inf') - leased
+	return {tonumber(workerID), leased, quarantined, total}
 `)
 
 func (g *RedisGenerator) GetID() (int64, string, error) {
-	token := generateToken()
-	now, err := g.getCurrentTime()
-	if err != nil {
-		return 0, "", fmt.Errorf("get current time failed: %w", err)
+	return g.getID(g.ctx, g.holder)
+}
+
+// GetIDContext 同 GetID，使用调用方的 context
+func (g *RedisGenerator) GetIDContext(ctx context.Context) (int64, string, error) {
+	return g.getID(ctx, g.holder)
+}
+
+// GetIDWithHolder 获取 WorkerID，并记录本次分配的持有者信息
+func (g *RedisGenerator) GetIDWithHolder(holder Holder) (int64, string, error) {
+	return g.getID(g.ctx, &holder)
+}
+
+func (g *RedisGenerator) getID(ctx context.Context, holder *Holder) (workerID int64, token string, err error) {
+	defer func() { g.finish(ctx, OpGetID, workerID, token, err) }()
+
+	// 启动后第一次分配时优先恢复上一次进程留下的租约
+	if g.resumePending.CompareAndSwap(true, false) {
+		if workerID, token, ok := g.resumeLease(ctx); ok {
+			return workerID, token, nil
+		}
 	}
-	result, err := getIDScript.Run(g.ctx, g.redisClient, []string{g.getIDsKey(), g.getTokenKey()},
-		now, g.leaseSeconds, token).Int64()
+
+	token = generateToken()
+	holderData := ""
+	if holder != nil {
+		data, err := json.Marshal(holder)
+		if err != nil {
+			return 0, "", fmt.Errorf("encode holder failed: %w", err)
+		}
+		holderData = string(data)
+	}
+	keys := []string{g.getIDsKey(), g.getTokenKey(), g.getHolderKey(), g.getEventChannel(), g.getReservedKey(),
+		g.getCursorKey(), g.getBitmapKey(), g.getMetaKey()}
+
+	var result []int64
+	var expireAt int64
+	if err := g.ensureInitialized(); err != nil {
+		return g.fallbackID(ctx, fmt.Errorf("initialize available IDs failed: %w", err))
+	}
+	err = g.retry(ctx, OpGetID, func(int) error {
+		now, err := g.getCurrentTime(ctx)
+		if err != nil {
+			return fmt.Errorf("get current time failed: %w", err)
+		}
+		expireAt = now + int64(g.leaseSeconds)
+		result, err = g.runScript(ctx, getIDScript, "get_id", keys, now, g.leaseSeconds, token, holderData,
+			now-g.reuseDelay, string(g.strategy), rand.Int32(), string(g.poolMode), g.maxWorkerID).Int64Slice()
+		if err != nil {
+			return fmt.Errorf("get ID failed: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return 0, "", fmt.Errorf("get ID failed: %w", err)
+		return g.fallbackID(ctx, err)
+	}
+
+	g.checkCapacity(ctx, result[1], result[2], result[3])
+	if result[0] < 0 {
+		return 0, "", ErrNoAvailableID
+	}
+	workerID = g.composeID(result[0])
+	g.saveLease(ctx, workerID, token, expireAt)
+	return workerID, token, nil
+}
+
+// checkCapacity 记录池的使用量，超过预警阈值时输出日志并触发 OnLowCapacity 回调
+func (g *RedisGenerator) checkCapacity(ctx context.Context, leased, quarantined, total int64) {
+	inUse := leased + quarantined
+	g.metrics.PoolUsage(g.metricsLabel, leased, total-inUse)
+	if g.capacityWarning <= 0 || total <= 0 || float64(inUse) < g.capacityWarning*float64(total) {
+		return
+	}
+
+	g.logger.WarnContext(ctx, "worker ID pool capacity low", slog.Int64("leased", leased),
+		slog.Int64("quarantined", quarantined), slog.Int64("total", total), slog.Float64("threshold", g.capacityWarning))
+	g.hooks.emitCapacity(CapacityEvent{
+		Cluster:     g.cluster,
+		Leased:      leased,
+		Quarantined: quarantined,
+		Total:       total,
+		Threshold:   g.capacityWarning,
+	})
+}
+
+// releaseScore 返回 ID 被释放或回收后在 Sorted Set 中的分数。
+// 设置了重用延迟时记录释放时间，使 ID 在隔离期结束后才能被重新分配；
+// 使用 AllocateLeastRecentlyUsed 策略时记录释放时间，使空闲最久的 ID 优先被分配。
+func (g *RedisGenerator) releaseScore(now int64) int64 {
+	if g.reuseDelay > 0 || g.strategy == AllocateLeastRecentlyUsed {
+		return now
 	}
-	return result, token, nil
+	return 0
 }
 
 var renewScript = redis.NewScript(`
@@ -177,104 +720,218 @@ var renewScript = redis.NewScript(`
 	redis.call('HSET', tokenKey, workerID, newTokenStr)
 	redis.call('ZADD', key, newExpireAt, workerID)
 	
-	-- 5. 重新设置 Token Hash 的过期时间，防止整个 Hash 过期
+	-- 5. 重新设置 Token Hash 和持有者 Hash 的过期时间，防止整个 Hash 过期
 	redis.call('EXPIRE', tokenKey, lease * 3)
+	if redis.call('HEXISTS', KEYS[3], workerID) == 1 then
+		redis.call('EXPIRE', KEYS[3], lease * 3)
+	end
 
 	return {ok="Success"}
 `)
 
 func (g *RedisGenerator) Renew(workerID int64, token string) error {
-	if workerID < 0 || workerID > int64(g.maxWorkerID) {
-		return ErrInvalidWorkerID
+	return g.RenewContext(g.ctx, workerID, token)
+}
+
+// RenewContext 同 Renew，使用调用方的 context
+func (g *RedisGenerator) RenewContext(ctx context.Context, workerID int64, token string) (err error) {
+	defer func() { g.finish(ctx, OpRenew, workerID, token, err) }()
+
+	id, err := g.machineID(workerID)
+	if err != nil {
+		return err
 	}
 	if len(token) != 22 {
 		return ErrInvalidToken
 	}
+	if unverified, ok := g.unverifiedToken(workerID); ok && unverified == token {
+		return g.reconcile(ctx, workerID, token)
+	}
+	return g.renew(ctx, id, workerID, token)
+}
 
-	now, err := g.getCurrentTime()
-	if err != nil {
-		return fmt.Errorf("get current time failed: %w", err)
+// renew 延长租约并记录到本地租约文件，id 为不含数据中心部分的机器 ID
+func (g *RedisGenerator) renew(ctx context.Context, id, workerID int64, token string) error {
+	var expireAt int64
+	err := g.retry(ctx, OpRenew, func(int) error {
+		now, err := g.getCurrentTime(ctx)
+		if err != nil {
+			return fmt.Errorf("get current time failed: %w", err)
+		}
+
+		expireAt = now + int64(g.leaseSeconds)
+		err = g.runScript(ctx, renewScript, "renew", []string{g.getTokenKey(), g.getIDsKey(), g.getHolderKey()},
+			id, token, now, g.leaseSeconds).Err()
+		if err != nil {
+			if scriptErr := parseScriptError(err); scriptErr != nil {
+				return scriptErr
+			}
+			return fmt.Errorf("renew failed: %w", err)
+		}
+		return nil
+	})
+	if err == nil {
+		g.saveLease(ctx, workerID, token, expireAt)
 	}
+	return err
+}
 
-	result, err := renewScript.Run(g.ctx, g.redisClient, []string{g.getTokenKey(), g.getIDsKey()},
-		workerID, token, now, g.leaseSeconds).Result()
-	if err != nil {
-		return fmt.Errorf("renew failed: %w", err)
+// finish 记录操作结果的指标和日志，并触发对应的生命周期回调
+func (g *RedisGenerator) finish(ctx context.Context, op Operation, workerID int64, token string, err error) {
+	g.metrics.OperationCompleted(g.metricsLabel, op, err)
+
+	event := operationEvent(op, err)
+	logger := g.logger
+	if err == nil || op != OpGetID {
+		logger = logger.With(slog.Int64("worker_id", workerID))
+	}
+	switch event {
+	case EventAcquired:
+		logger.InfoContext(ctx, "worker ID acquired")
+	case EventRenewed:
+		logger.DebugContext(ctx, "worker ID renewed")
+	case EventReleased:
+		logger.InfoContext(ctx, "worker ID released")
+	case EventLost:
+		logger.WarnContext(ctx, "worker ID lease lost", slog.Any("error", err))
+	case EventRenewFailed:
+		logger.WarnContext(ctx, "renew worker ID failed", slog.Any("error", err))
+	default:
+		if op == OpGetID {
+			logger.ErrorContext(ctx, "acquire worker ID failed", slog.Any("error", err))
+		} else {
+			logger.WarnContext(ctx, string(op)+" worker ID failed", slog.Any("error", err))
+		}
 	}
 
-	if result == "Token not found" {
-		return ErrNotAssigned
+	if event != "" {
+		g.hooks.emit(LeaseEvent{Type: event, Cluster: g.cluster, WorkerID: workerID, Token: token, Err: err})
 	}
-	if result == "Token mismatch" {
+}
+
+// runScript 执行 Lua 脚本并记录执行耗时
+func (g *RedisGenerator) runScript(ctx context.Context, script *redis.Script, name string,
+	keys []string, args ...any) *redis.Cmd {
+	start := time.Now()
+	cmd := script.Run(ctx, g.redisClient, keys, args...)
+	g.metrics.ScriptExecuted(g.metricsLabel, name, time.Since(start))
+	return cmd
+}
+
+// parseScriptError 将 Lua 脚本返回的错误转换为预定义错误，无法识别时返回 nil
+func parseScriptError(err error) error {
+	switch err.Error() {
+	case "Token not found":
+		return ErrNotAssigned
+	case "Token mismatch":
 		return ErrTokenMismatch
-	}
-	if result == "Token expired" {
+	case "Token expired":
 		return ErrTokenExpired
+	case "Invalid token format":
+		return ErrInvalidToken
 	}
-
 	return nil
 }
 
+var releaseScript = redis.NewScript(`
+	local tokenKey = KEYS[1]
+	local key = KEYS[2]
+	local holderKey = KEYS[3]
+	local channel = KEYS[4]
+	local workerID = ARGV[1]
+	local token = ARGV[2]
+	local now = tonumber(ARGV[3])
+
+	-- 1. 获取 Token 记录
+	local tokenStr = redis.call('HGET', tokenKey, workerID)
+	if not tokenStr then
+		return {err="Token not found"}
+	end
+	local colonPos = string.find(tokenStr, ":")
+	if not colonPos then
+		return {err="Invalid token format"}
+	end
+
+	-- 2. 验证 Token 匹配性
+	if string.sub(tokenStr, 1, colonPos-1) ~= token then
+		return {err="Token mismatch"}
+	end
+
+	-- 3. 验证 Token 未过期
+	local expireAt = tonumber(string.sub(tokenStr, colonPos+1))
+	if not expireAt or expireAt <= now then
+		return {err="Token expired"}
+	end
+
+	-- 4. 删除 Token 和持有者记录，重置 ID 的分数（标记为可用或进入隔离期），已被预留的 ID 直接移出池。
+	-- bitmap 模式下无需隔离的 ID 直接移出池并清除在用标记
+	redis.call('HDEL', tokenKey, workerID)
+	redis.call('HDEL', holderKey, workerID)
+	if redis.call('HEXISTS', KEYS[5], workerID) == 1 then
+		redis.call('ZREM', key, workerID)
+	elseif ARGV[5] == 'bitmap' and tonumber(ARGV[4]) == 0 then
+		redis.call('ZREM', key, workerID)
+		redis.call('SETBIT', KEYS[6], workerID, 0)
+	else
+		redis.call('ZADD', key, tonumber(ARGV[4]), workerID)
+	end
+
+	-- 5. 通知等待者
+	redis.call('PUBLISH', channel, cjson.encode({type='released', worker_id=tonumber(workerID), time=now}))
+
+	return {ok="Success"}
+`)
+
 // Release 主动释放 WorkerID（使其可被重新分配）
 func (g *RedisGenerator) Release(workerID int64, token string) error {
-	if workerID < 0 || workerID > int64(g.maxWorkerID) {
-		return ErrInvalidWorkerID
-	}
-
-	if len(token) != 22 {
-		return ErrInvalidToken
-	}
+	return g.ReleaseContext(g.ctx, workerID, token)
+}
 
-	key := g.getIDsKey()
-	tokenKey := g.getTokenKey()
-	now, err := g.getCurrentTime()
-	if err != nil {
-		return fmt.Errorf("get current time failed: %w", err)
-	}
+// ReleaseContext 同 Release，使用调用方的 context
+func (g *RedisGenerator) ReleaseContext(ctx context.Context, workerID int64, token string) (err error) {
+	defer func() { g.finish(ctx, OpRelease, workerID, token, err) }()
 
-	// 1. 获取 Token 记录（原子操作）
-	tokenStr, err := g.redisClient.HGet(g.ctx, tokenKey, strconv.FormatInt(workerID, 10)).Result()
+	id, err := g.machineID(workerID)
 	if err != nil {
-		return fmt.Errorf("get token failed: %w", err)
-	}
-	if tokenStr == "" {
-		return ErrNotAssigned
+		return err
 	}
 
-	tokenData := strings.Split(tokenStr, ":")
-	if len(tokenData) != 2 {
+	if len(token) != 22 {
 		return ErrInvalidToken
 	}
-
-	// 2. 验证 Token 匹配性
-	storedToken := tokenData[0]
-	if storedToken != token {
-		return ErrTokenMismatch
-	}
-
-	// 3. 验证 Token 未过期
-	expireAt, err := strconv.ParseInt(tokenData[1], 10, 64)
-	if err != nil {
-		return fmt.Errorf("invalid expire_at format: %w", err)
-	}
-	if expireAt <= now {
-		return ErrTokenExpired
+	// 未确认的租约在 Redis 中没有记录，只需在本地丢弃
+	if unverified, ok := g.unverifiedToken(workerID); ok && unverified == token {
+		g.degraded.mu.Lock()
+		delete(g.degraded.unverified, workerID)
+		g.degraded.mu.Unlock()
+		return nil
 	}
 
-	// 4. 删除 Token 记录（原子操作）
-	_, err = g.redisClient.HDel(g.ctx, tokenKey, strconv.FormatInt(workerID, 10)).Result()
-	if err != nil {
-		return fmt.Errorf("delete token failed: %w", err)
-	}
+	keys := []string{g.getTokenKey(), g.getIDsKey(), g.getHolderKey(), g.getEventChannel(), g.getReservedKey(),
+		g.getBitmapKey()}
+	err = g.retry(ctx, OpRelease, func(attempt int) error {
+		now, err := g.getCurrentTime(ctx)
+		if err != nil {
+			return fmt.Errorf("get current time failed: %w", err)
+		}
 
-	// 5. 重置 ID 的过期时间（标记为可用）
-	_, err = g.redisClient.ZAdd(g.ctx, key, &redis.Z{
-		Score:  0,
-		Member: workerID,
-	}).Result()
-	if err != nil {
-		return fmt.Errorf("reset expire time failed: %w", err)
+		err = g.runScript(ctx, releaseScript, "release", keys,
+			id, token, now, g.releaseScore(now), string(g.poolMode)).Err()
+		if err != nil {
+			scriptErr := parseScriptError(err)
+			// 上一次尝试可能已在连接中断前释放成功
+			if attempt > 1 && errors.Is(scriptErr, ErrNotAssigned) {
+				return nil
+			}
+			if scriptErr != nil {
+				return scriptErr
+			}
+			return fmt.Errorf("release failed: %w", err)
+		}
+		return nil
+	})
+	if err == nil {
+		g.removeLease(ctx, workerID, token)
 	}
-
-	return nil
+	return err
 }
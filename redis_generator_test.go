@@ -1,11 +1,15 @@
 package workerid
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,7 +17,7 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
-func setupTestRedis(t *testing.T) (*redis.Client, func()) {
+func setupTestRedis(t testing.TB) (*redis.Client, func()) {
 	// 启动 miniredis 服务器
 	mr, err := miniredis.Run()
 	if err != nil {
@@ -67,6 +71,12 @@ func TestNewRedisGenerator(t *testing.T) {
 			options: nil,
 			wantErr: true,
 		},
+		{
+			name:    "租约时间不足1秒",
+			cluster: "test-cluster-3",
+			options: []Option{WithMaxLeaseTime(500 * time.Millisecond)},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -139,10 +149,11 @@ func TestRedisGenerator_Renew(t *testing.T) {
 	}
 
 	tests := []struct {
-		name     string
-		workerID int64
-		token    string
-		wantErr  bool
+		name      string
+		workerID  int64
+		token     string
+		wantErr   bool
+		wantErrIs error
 	}{
 		{
 			name:     "正确续期",
@@ -169,16 +180,20 @@ func TestRedisGenerator_Renew(t *testing.T) {
 			wantErr:  true,
 		},
 		{
-			name:     "错误的Token",
-			workerID: workerID,
-			token:    "abcdefghijklmnopqrstuv",
-			wantErr:  true,
+			name:      "错误的Token",
+			workerID:  workerID,
+			token:     "abcdefghijklmnopqrstuv",
+			wantErr:   true,
+			wantErrIs: ErrTokenMismatch,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := gen.Renew(tt.workerID, tt.token)
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("Renew() 错误 = %v, 期望 %v", err, tt.wantErrIs)
+			}
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Renew() 错误 = %v, 期望错误 %v", err, tt.wantErr)
 			}
@@ -763,3 +778,639 @@ func TestWithWorkerBits(t *testing.T) {
 		}
 	}
 }
+
+func TestRedisGenerator_GetIDWithHolder(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	defaultHolder := Holder{Hostname: "host-a", Pod: "pod-a", PID: 42, Version: "v1.0.0"}
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithHolder(defaultHolder))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	ctx := context.Background()
+	holderKey := gen.getHolderKey()
+
+	// GetID 使用 WithHolder 设置的默认持有者
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	data, err := client.HGet(ctx, holderKey, strconv.FormatInt(workerID, 10)).Result()
+	if err != nil {
+		t.Fatalf("获取持有者信息失败: %v", err)
+	}
+	if !strings.Contains(data, `"hostname":"host-a"`) || !strings.Contains(data, `"pid":42`) {
+		t.Errorf("持有者信息不正确: %s", data)
+	}
+
+	// GetIDWithHolder 使用本次指定的持有者
+	holder := Holder{Hostname: "host-b", Labels: map[string]string{"zone": "az1"}}
+	workerID2, _, err := gen.GetIDWithHolder(holder)
+	if err != nil {
+		t.Fatalf("GetIDWithHolder() 失败: %v", err)
+	}
+
+	leases, err := gen.ListLeases(ctx)
+	if err != nil {
+		t.Fatalf("ListLeases() 失败: %v", err)
+	}
+	for _, lease := range leases {
+		switch lease.WorkerID {
+		case workerID:
+			if lease.Holder == nil || lease.Holder.Pod != "pod-a" || lease.Holder.Version != "v1.0.0" {
+				t.Errorf("WorkerID %d 的持有者信息不正确: %+v", workerID, lease.Holder)
+			}
+		case workerID2:
+			if lease.Holder == nil || lease.Holder.Hostname != "host-b" || lease.Holder.Labels["zone"] != "az1" {
+				t.Errorf("WorkerID %d 的持有者信息不正确: %+v", workerID2, lease.Holder)
+			}
+		default:
+			if lease.Holder != nil {
+				t.Errorf("空闲 WorkerID %d 不应该有持有者信息: %+v", lease.WorkerID, lease.Holder)
+			}
+		}
+	}
+
+	// 释放后持有者信息应该被清除
+	if err := gen.Release(workerID, token); err != nil {
+		t.Fatalf("Release() 失败: %v", err)
+	}
+	exists, err := client.HExists(ctx, holderKey, strconv.FormatInt(workerID, 10)).Result()
+	if err != nil {
+		t.Fatalf("检查持有者信息失败: %v", err)
+	}
+	if exists {
+		t.Error("释放后持有者信息应该已移除")
+	}
+}
+
+func TestRedisGenerator_WithLogger(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if err := gen.Renew(workerID, "abcdefghijklmnopqrstuv"); err == nil {
+		t.Fatal("Renew() 使用错误的 Token 应该返回错误")
+	}
+	if err := gen.Release(workerID, token); err != nil {
+		t.Fatalf("Release() 失败: %v", err)
+	}
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("解析日志失败: %v, 日志: %s", err, line)
+		}
+		records = append(records, record)
+	}
+
+	want := []struct {
+		level string
+		msg   string
+	}{
+		{"INFO", "worker ID pool initialized"},
+		{"INFO", "worker ID acquired"},
+		{"WARN", "worker ID lease lost"},
+		{"INFO", "worker ID released"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("日志数量应该为 %d, 实际值: %d\n%s", len(want), len(records), buf.String())
+	}
+	for i, w := range want {
+		if records[i]["level"] != w.level || records[i]["msg"] != w.msg {
+			t.Errorf("第 %d 条日志应该为 %s %q, 实际值: %v %v", i, w.level, w.msg, records[i]["level"], records[i]["msg"])
+		}
+		if records[i]["cluster"] != "test-cluster" {
+			t.Errorf("第 %d 条日志应该包含 cluster 属性: %v", i, records[i])
+		}
+		if i > 0 && records[i]["worker_id"] != float64(workerID) {
+			t.Errorf("第 %d 条日志应该包含 worker_id 属性: %v", i, records[i])
+		}
+	}
+}
+
+func TestRedisGenerator_WithReuseDelay(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(1), WithReuseDelay(time.Hour))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	ctx := context.Background()
+	idsKey := gen.getIDsKey()
+	workerID1, token1, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	workerID2, _, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+
+	// 释放的 ID 进入隔离期，不会被立即重新分配
+	if err := gen.Release(workerID1, token1); err != nil {
+		t.Fatalf("Release() 失败: %v", err)
+	}
+	if _, _, err := gen.GetID(); !errors.Is(err, ErrNoAvailableID) {
+		t.Fatalf("隔离期内 GetID() 应该返回 ErrNoAvailableID, 实际值: %v", err)
+	}
+
+	stats, err := gen.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if stats.Quarantined != 1 || stats.Leased != 1 || stats.Free != 0 {
+		t.Errorf("统计不正确: %+v", stats)
+	}
+	leases, err := gen.ListLeases(ctx)
+	if err != nil {
+		t.Fatalf("ListLeases() 失败: %v", err)
+	}
+	quarantined := leases[workerID1]
+	if quarantined.State != LeaseQuarantined {
+		t.Errorf("WorkerID %d 的状态应该为 quarantined, 实际值: %s", workerID1, quarantined.State)
+	}
+	if d := time.Until(quarantined.ReusableAt); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("隔离期结束时间应该约为一小时后, 实际值: %v", quarantined.ReusableAt)
+	}
+
+	// 过期的 ID 同样需要经过隔离期
+	expireAt := time.Now().Add(-time.Minute).Unix()
+	if err := client.ZAdd(ctx, idsKey, &redis.Z{Score: float64(expireAt), Member: strconv.FormatInt(workerID2, 10)}).Err(); err != nil {
+		t.Fatalf("设置过期时间失败: %v", err)
+	}
+	if _, _, err := gen.GetID(); !errors.Is(err, ErrNoAvailableID) {
+		t.Fatalf("过期 ID 在隔离期内 GetID() 应该返回 ErrNoAvailableID, 实际值: %v", err)
+	}
+
+	// 隔离期结束后可以重新分配
+	releasedAt := time.Now().Add(-time.Hour - time.Second).Unix()
+	if err := client.ZAdd(ctx, idsKey, &redis.Z{Score: float64(releasedAt), Member: strconv.FormatInt(workerID1, 10)}).Err(); err != nil {
+		t.Fatalf("设置释放时间失败: %v", err)
+	}
+	workerID, _, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("隔离期结束后 GetID() 失败: %v", err)
+	}
+	if workerID != workerID1 {
+		t.Errorf("应该重新分配 WorkerID %d, 实际值: %d", workerID1, workerID)
+	}
+}
+
+func TestRedisGenerator_WithAllocationStrategy(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	if _, err := NewRedisGenerator(client, "invalid", WithAllocationStrategy("unknown")); err == nil {
+		t.Error("未知的分配策略应该返回错误")
+	}
+
+	// 反复获取并释放 ID，统计分配到的不同 ID 数量
+	distinctIDs := func(mode PoolMode, strategy AllocationStrategy) map[int64]bool {
+		gen, err := NewRedisGenerator(client, "cluster-"+string(mode)+"-"+string(strategy), WithWorkerBits(4),
+			WithPoolMode(mode), WithAllocationStrategy(strategy))
+		if err != nil {
+			t.Fatalf("创建 RedisGenerator 失败: %v", err)
+		}
+		ids := make(map[int64]bool)
+		for i := 0; i < 16; i++ {
+			workerID, token, err := gen.GetID()
+			if err != nil {
+				t.Fatalf("%s: GetID() 失败: %v", strategy, err)
+			}
+			ids[workerID] = true
+			if err := gen.Release(workerID, token); err != nil {
+				t.Fatalf("%s: Release() 失败: %v", strategy, err)
+			}
+		}
+		return ids
+	}
+
+	// lazy 和 bitmap 模式下从未分配过的 ID 同样参与 lru 和 random 的选择
+	for _, mode := range []PoolMode{PoolModeEager, PoolModeLazy, PoolModeBitmap} {
+		t.Run(string(mode), func(t *testing.T) {
+			if ids := distinctIDs(mode, AllocateLowest); len(ids) != 1 {
+				t.Errorf("lowest 策略应该始终重用同一个 ID, 实际分配了 %d 个不同的 ID", len(ids))
+			}
+			// 从未使用的 ID 空闲最久，lru 策略会依次分配所有 ID
+			if ids := distinctIDs(mode, AllocateLeastRecentlyUsed); len(ids) != 16 {
+				t.Errorf("lru 策略应该轮流分配所有 ID, 实际分配了 %d 个不同的 ID", len(ids))
+			}
+			if ids := distinctIDs(mode, AllocateRandom); len(ids) < 2 {
+				t.Errorf("random 策略应该分配到不同的 ID, 实际分配了 %d 个不同的 ID", len(ids))
+			}
+		})
+	}
+}
+
+func TestRedisGenerator_WithDatacenter(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// 3 位数据中心 ID 和 2 位机器 ID，两个数据中心共用同一个 Redis
+	dc1, err := NewRedisGenerator(client, "test-cluster", WithDatacenter(1, 3, 2))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	dc5, err := NewRedisGenerator(client, "test-cluster", WithDatacenter(5, 3, 2))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	for _, tt := range []struct {
+		gen        *RedisGenerator
+		datacenter int64
+	}{{dc1, 1}, {dc5, 5}} {
+		seen := map[int64]string{}
+		for i := 0; i < 4; i++ {
+			workerID, token, err := tt.gen.GetID()
+			if err != nil {
+				t.Fatalf("数据中心 %d 第 %d 次 GetID() 失败: %v", tt.datacenter, i, err)
+			}
+			if workerID>>2 != tt.datacenter {
+				t.Errorf("WorkerID %d 的数据中心部分应该为 %d", workerID, tt.datacenter)
+			}
+			seen[workerID] = token
+		}
+		if len(seen) != 4 {
+			t.Errorf("数据中心 %d 应分配 4 个不同的 WorkerID, 实际值: %v", tt.datacenter, seen)
+		}
+		if _, _, err := tt.gen.GetID(); !errors.Is(err, ErrNoAvailableID) {
+			t.Errorf("数据中心 %d 的池耗尽后应该返回 ErrNoAvailableID, 实际值: %v", tt.datacenter, err)
+		}
+		for workerID, token := range seen {
+			if err := tt.gen.Renew(workerID, token); err != nil {
+				t.Errorf("Renew(%d) 失败: %v", workerID, err)
+			}
+		}
+	}
+
+	// 其他数据中心的 WorkerID 无效
+	if err := dc1.Renew(5<<2, "abcdefghijklmnopqrstuv"); !errors.Is(err, ErrInvalidWorkerID) {
+		t.Errorf("续期其他数据中心的 WorkerID 应该返回 ErrInvalidWorkerID, 实际值: %v", err)
+	}
+	if err := dc1.Revoke(ctx, 5<<2, "test", ""); !errors.Is(err, ErrInvalidWorkerID) {
+		t.Errorf("回收其他数据中心的 WorkerID 应该返回 ErrInvalidWorkerID, 实际值: %v", err)
+	}
+
+	// 管理接口返回组合后的 WorkerID
+	if err := dc5.Revoke(ctx, 5<<2|3, "test", ""); err != nil {
+		t.Fatalf("Revoke() 失败: %v", err)
+	}
+	leases, err := dc5.ListLeases(ctx)
+	if err != nil {
+		t.Fatalf("ListLeases() 失败: %v", err)
+	}
+	if leases[3].WorkerID != 5<<2|3 || leases[3].State != LeaseFree {
+		t.Errorf("ListLeases 应该返回组合后的 WorkerID, 实际值: %+v", leases[3])
+	}
+	entries, err := dc5.AuditLog(ctx, 1)
+	if err != nil {
+		t.Fatalf("AuditLog() 失败: %v", err)
+	}
+	if len(entries) != 1 || entries[0].WorkerID != 5<<2|3 {
+		t.Errorf("审计记录应该包含组合后的 WorkerID, 实际值: %+v", entries)
+	}
+
+	if _, err := NewRedisGenerator(client, "test-cluster", WithDatacenter(8, 3, 7)); err == nil {
+		t.Error("数据中心 ID 超出位数时应该返回错误")
+	}
+
+	// 以后设置的选项为准，WithWorkerBits 取消数据中心划分
+	plain, err := NewRedisGenerator(client, "plain-cluster", WithDatacenter(3, 3, 7), WithWorkerBits(10))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	if plain.pool != "plain-cluster" {
+		t.Errorf("WithWorkerBits 之后不应使用数据中心的池, 实际值: %s", plain.pool)
+	}
+	if workerID, _, err := plain.GetID(); err != nil || workerID >= 1<<10 {
+		t.Errorf("WorkerID 应该在 10 位以内, 实际值: %d, 错误: %v", workerID, err)
+	}
+	dc3, err := NewRedisGenerator(client, "plain-cluster", WithWorkerBits(10), WithDatacenter(3, 3, 7))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	if workerID, _, err := dc3.GetID(); err != nil || workerID>>7 != 3 {
+		t.Errorf("WorkerID 的数据中心部分应该为 3, 实际值: %d, 错误: %v", workerID, err)
+	}
+}
+
+func TestRedisGenerator_InitAvailableIDs(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(3))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	expireAt := client.ZScore(ctx, gen.getIDsKey(), strconv.FormatInt(workerID, 10)).Val()
+
+	// 已初始化的池不会被重新初始化，已分配 ID 的过期时间保持不变
+	if _, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(3)); err != nil {
+		t.Fatalf("重复创建 RedisGenerator 失败: %v", err)
+	}
+	if score := client.ZScore(ctx, gen.getIDsKey(), strconv.FormatInt(workerID, 10)).Val(); score != expireAt {
+		t.Errorf("重复初始化不应重置已分配 ID 的分数, 期望: %v, 实际值: %v", expireAt, score)
+	}
+
+	// 模拟旧版本初始化中途失败留下的不完整池：没有元数据且缺少部分 ID
+	if err := client.Del(ctx, gen.getMetaKey()).Err(); err != nil {
+		t.Fatalf("删除元数据失败: %v", err)
+	}
+	if err := client.ZRem(ctx, gen.getIDsKey(), "5", "6", "7").Err(); err != nil {
+		t.Fatalf("删除 ID 失败: %v", err)
+	}
+	if _, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(3)); err != nil {
+		t.Fatalf("修复池时创建 RedisGenerator 失败: %v", err)
+	}
+	if n := client.ZCard(ctx, gen.getIDsKey()).Val(); n != 8 {
+		t.Errorf("修复后池中应有 8 个 ID, 实际值: %d", n)
+	}
+	if err := gen.Renew(workerID, token); err != nil {
+		t.Errorf("修复后原租约应该保持有效: %v", err)
+	}
+	meta := client.HGetAll(ctx, gen.getMetaKey()).Val()
+	if meta["version"] != poolVersion || meta["max_worker_id"] != "7" {
+		t.Errorf("元数据不正确: %v", meta)
+	}
+
+	// 池的范围以元数据为准，使用不同位数创建的实例不会扩大或缩小共享的池
+	if _, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(8)); !errors.Is(err, ErrPoolMismatch) {
+		t.Errorf("位数与池的元数据不一致时应该返回 ErrPoolMismatch, 实际值: %v", err)
+	}
+	if err := gen.Resize(ctx, 2, true); err != nil {
+		t.Fatalf("Resize() 失败: %v", err)
+	}
+	if _, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(3)); !errors.Is(err, ErrPoolMismatch) {
+		t.Errorf("Resize 后使用旧的位数创建应该返回 ErrPoolMismatch, 实际值: %v", err)
+	}
+	if _, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2)); err != nil {
+		t.Errorf("Resize 后使用新的位数创建失败: %v", err)
+	}
+	if n := client.ZCard(ctx, gen.getIDsKey()).Val(); n != 4 {
+		t.Errorf("池中应有 4 个 ID, 实际值: %d", n)
+	}
+
+	// 并发启动的多个实例只会初始化一次
+	other, cleanupOther := setupTestRedis(t)
+	defer cleanupOther()
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := NewRedisGenerator(other, "test-cluster", WithWorkerBits(4))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("并发创建 RedisGenerator 失败: %v", err)
+		}
+	}
+	if n := other.ZCard(ctx, gen.getIDsKey()).Val(); n != 16 {
+		t.Errorf("并发初始化后池中应有 16 个 ID, 实际值: %d", n)
+	}
+}
+
+// scriptCounter 统计每个脚本的执行次数
+type scriptCounter struct {
+	nopMetrics
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *scriptCounter) ScriptExecuted(_, script string, _ time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[script]++
+}
+
+func TestRedisGenerator_InitInBatches(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// eager 模式分批写入 ID，每批不超过 initBatchSize 个
+	counter := &scriptCounter{counts: make(map[string]int)}
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(13), WithMetrics(counter))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	if n := client.ZCard(ctx, gen.getIDsKey()).Val(); n != 8192 {
+		t.Errorf("池中应有 8192 个 ID, 实际值: %d", n)
+	}
+	if n := counter.counts["init"]; n != 8192/initBatchSize {
+		t.Errorf("初始化脚本应该执行 %d 次, 实际值: %d", 8192/initBatchSize, n)
+	}
+	if meta := client.HGetAll(ctx, gen.getMetaKey()).Val(); meta["max_worker_id"] != "8191" {
+		t.Errorf("全部写入后才记录元数据, 实际值: %v", meta)
+	}
+
+	// 已初始化的池只执行一次脚本
+	counter.counts = make(map[string]int)
+	if _, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(13), WithMetrics(counter)); err != nil {
+		t.Fatalf("重复创建 RedisGenerator 失败: %v", err)
+	}
+	if n := counter.counts["init"]; n != 1 {
+		t.Errorf("已初始化的池应该只执行 1 次初始化脚本, 实际值: %d", n)
+	}
+}
+
+func TestRedisGenerator_WithPoolMode(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(16), WithPoolMode(PoolModeLazy),
+		WithReservedRange(1, 1))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	// 初始化时不写入任何 ID
+	if n := client.ZCard(ctx, gen.getIDsKey()).Val(); n != 0 {
+		t.Errorf("lazy 模式初始化后池中不应有 ID, 实际值: %d", n)
+	}
+	stats, err := gen.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if stats.Total != 1<<16-1 || stats.Free != 1<<16-1 {
+		t.Errorf("lazy 模式的容量应该为 65535, 实际值: %+v", stats)
+	}
+
+	// 游标按顺序发放 ID 并跳过预留的 ID
+	var tokens []string
+	for _, want := range []int64{0, 2, 3} {
+		workerID, token, err := gen.GetID()
+		if err != nil {
+			t.Fatalf("GetID() 失败: %v", err)
+		}
+		if workerID != want {
+			t.Errorf("WorkerID 应该为 %d, 实际值: %d", want, workerID)
+		}
+		tokens = append(tokens, token)
+	}
+	if n := client.ZCard(ctx, gen.getIDsKey()).Val(); n != 3 {
+		t.Errorf("池中应只有 3 个分配过的 ID, 实际值: %d", n)
+	}
+
+	// 释放的 ID 优先被重用
+	if err := gen.Release(2, tokens[1]); err != nil {
+		t.Fatalf("Release() 失败: %v", err)
+	}
+	if workerID, _, err := gen.GetID(); err != nil || workerID != 2 {
+		t.Errorf("应该重用释放的 WorkerID 2, 实际值: %d, %v", workerID, err)
+	}
+
+	// 同一个池不能混用存储方式
+	if _, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(16)); err == nil {
+		t.Error("使用不同的存储方式打开已有的池应该返回错误")
+	}
+
+	// 缩容后再扩容，被移除的 ID 可以重新发放
+	small, err := NewRedisGenerator(client, "small", WithWorkerBits(2), WithPoolMode(PoolModeLazy))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, _, err := small.GetID(); err != nil {
+			t.Fatalf("GetID() 失败: %v", err)
+		}
+	}
+	if _, _, err := small.GetID(); !errors.Is(err, ErrNoAvailableID) {
+		t.Errorf("池耗尽后应该返回 ErrNoAvailableID, 实际值: %v", err)
+	}
+	if err := small.Resize(ctx, 1, true); err != nil {
+		t.Fatalf("Resize() 失败: %v", err)
+	}
+	if err := small.Resize(ctx, 2, false); err != nil {
+		t.Fatalf("Resize() 失败: %v", err)
+	}
+	got := map[int64]bool{}
+	for i := 0; i < 2; i++ {
+		workerID, _, err := small.GetID()
+		if err != nil {
+			t.Fatalf("扩容后 GetID() 失败: %v", err)
+		}
+		got[workerID] = true
+	}
+	if !got[2] || !got[3] {
+		t.Errorf("扩容后应该重新发放 WorkerID 2 和 3, 实际值: %v", got)
+	}
+
+	if err := small.Reset(ctx, true); err != nil {
+		t.Fatalf("Reset() 失败: %v", err)
+	}
+	if workerID, _, err := small.GetID(); err != nil || workerID != 0 {
+		t.Errorf("Reset 后应该从 WorkerID 0 开始发放, 实际值: %d, %v", workerID, err)
+	}
+
+	if _, err := NewRedisGenerator(client, "test-cluster", WithPoolMode("unknown")); err == nil {
+		t.Error("未知的存储方式应该返回错误")
+	}
+}
+
+func TestRedisGenerator_PoolModeBitmap(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(20), WithPoolMode(PoolModeBitmap),
+		WithReservedRange(0, 1))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	if n := client.ZCard(ctx, gen.getIDsKey()).Val(); n != 0 {
+		t.Errorf("bitmap 模式初始化后池中不应有 ID, 实际值: %d", n)
+	}
+
+	// 分配最小的空闲 ID，跳过预留的 ID
+	tokens := map[int64]string{}
+	for _, want := range []int64{2, 3, 4} {
+		workerID, token, err := gen.GetID()
+		if err != nil {
+			t.Fatalf("GetID() 失败: %v", err)
+		}
+		if workerID != want {
+			t.Errorf("WorkerID 应该为 %d, 实际值: %d", want, workerID)
+		}
+		tokens[workerID] = token
+	}
+
+	// 释放后清除在用标记，ID 被重新分配
+	if err := gen.Release(3, tokens[3]); err != nil {
+		t.Fatalf("Release() 失败: %v", err)
+	}
+	if n := client.ZCard(ctx, gen.getIDsKey()).Val(); n != 2 {
+		t.Errorf("释放后池中应只有 2 个在用的 ID, 实际值: %d", n)
+	}
+	if workerID, _, err := gen.GetID(); err != nil || workerID != 3 {
+		t.Errorf("应该重新分配 WorkerID 3, 实际值: %d, %v", workerID, err)
+	}
+	if err := gen.Revoke(ctx, 4, "test", ""); err != nil {
+		t.Fatalf("Revoke() 失败: %v", err)
+	}
+
+	stats, err := gen.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if stats.Total != 1<<20-2 || stats.Leased != 2 || stats.Free != 1<<20-4 {
+		t.Errorf("统计信息不正确: %+v", stats)
+	}
+
+	// 过期的 ID 可以被重新分配
+	expired, err := NewRedisGenerator(client, "expired", WithWorkerBits(1), WithPoolMode(PoolModeBitmap),
+		WithMaxLeaseTime(time.Second))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, err := expired.GetID(); err != nil {
+			t.Fatalf("GetID() 失败: %v", err)
+		}
+	}
+	if _, _, err := expired.GetID(); !errors.Is(err, ErrNoAvailableID) {
+		t.Errorf("池耗尽后应该返回 ErrNoAvailableID, 实际值: %v", err)
+	}
+	if err := client.ZAdd(ctx, expired.getIDsKey(), &redis.Z{Score: 1, Member: "1"}).Err(); err != nil {
+		t.Fatalf("修改过期时间失败: %v", err)
+	}
+	if workerID, _, err := expired.GetID(); err != nil || workerID != 1 {
+		t.Errorf("应该重新分配过期的 WorkerID 1, 实际值: %d, %v", workerID, err)
+	}
+
+	// Reset 后重新标记预留的 ID
+	if err := gen.Reset(ctx, true); err != nil {
+		t.Fatalf("Reset() 失败: %v", err)
+	}
+	if workerID, _, err := gen.GetID(); err != nil || workerID != 2 {
+		t.Errorf("Reset 后应该从 WorkerID 2 开始分配, 实际值: %d, %v", workerID, err)
+	}
+}
@@ -0,0 +1,81 @@
+package workerid
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SweepResult 一次清理的结果
+type SweepResult struct {
+	// Reclaimed 被清理的过期 WorkerID
+	Reclaimed []int64 `json:"reclaimed"`
+}
+
+var sweepScript = redis.NewScript(`
+	local tokenKey = KEYS[1]
+	local holderKey = KEYS[2]
+	local channel = KEYS[3]
+	local now = tonumber(ARGV[1])
+
+	local reclaimed = {}
+	local tokens = redis.call('HGETALL', tokenKey)
+	for i = 1, #tokens, 2 do
+		local workerID = tokens[i]
+		local colonPos = string.find(tokens[i+1], ":")
+		local expireAt = colonPos and tonumber(string.sub(tokens[i+1], colonPos+1))
+		-- 清理已过期或格式错误的 Token 记录，ID 本身已可通过分数被重新分配
+		if not expireAt or expireAt <= now then
+			redis.call('HDEL', tokenKey, workerID)
+			redis.call('HDEL', holderKey, workerID)
+			redis.call('PUBLISH', channel, cjson.encode({type='expired', worker_id=tonumber(workerID), time=now}))
+			table.insert(reclaimed, tonumber(workerID))
+		end
+	end
+
+	return reclaimed
+`)
+
+// Sweep 清理已过期租约遗留的 Token 和持有者记录，并为每个被清理的 ID 发布 expired 事件
+func (g *RedisGenerator) Sweep(ctx context.Context) (SweepResult, error) {
+	var result SweepResult
+	now, err := g.getCurrentTime(ctx)
+	if err != nil {
+		return result, fmt.Errorf("get current time failed: %w", err)
+	}
+
+	keys := []string{g.getTokenKey(), g.getHolderKey(), g.getEventChannel()}
+	reclaimed, err := g.runScript(ctx, sweepScript, "sweep", keys, now).Int64Slice()
+	if err != nil {
+		return result, fmt.Errorf("sweep failed: %w", err)
+	}
+
+	for i := range reclaimed {
+		reclaimed[i] = g.composeID(reclaimed[i])
+	}
+	result.Reclaimed = reclaimed
+	for _, workerID := range reclaimed {
+		g.logger.InfoContext(ctx, "expired worker ID reclaimed", slog.Int64("worker_id", workerID))
+	}
+	return result, nil
+}
+
+// RunSweeper 每隔 interval 执行一次 Sweep，直到 ctx 结束，通常在独立的 goroutine 中运行
+func (g *RedisGenerator) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := g.Sweep(ctx); err != nil && ctx.Err() == nil {
+				g.logger.WarnContext(ctx, "sweep expired worker IDs failed", slog.Any("error", err))
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package workerid
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRedisGenerator_Sweep(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithHolder(Holder{Hostname: "host-a"}))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	ctx := context.Background()
+	liveID, liveToken, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	expiredID, _, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+
+	// 将第二个租约改为已过期
+	expireAt := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	if err := client.HSet(ctx, gen.getTokenKey(), strconv.FormatInt(expiredID, 10),
+		"abcdefghijklmnopqrstuv:"+expireAt).Err(); err != nil {
+		t.Fatalf("写入过期 Token 失败: %v", err)
+	}
+
+	events, err := gen.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() 失败: %v", err)
+	}
+
+	result, err := gen.Sweep(ctx)
+	if err != nil {
+		t.Fatalf("Sweep() 失败: %v", err)
+	}
+	if len(result.Reclaimed) != 1 || result.Reclaimed[0] != expiredID {
+		t.Fatalf("应该清理 WorkerID %d, 实际值: %v", expiredID, result.Reclaimed)
+	}
+
+	for _, key := range []string{gen.getTokenKey(), gen.getHolderKey()} {
+		exists, err := client.HExists(ctx, key, strconv.FormatInt(expiredID, 10)).Result()
+		if err != nil {
+			t.Fatalf("检查 %s 失败: %v", key, err)
+		}
+		if exists {
+			t.Errorf("过期 ID 在 %s 中的记录应该已被清理", key)
+		}
+	}
+
+	select {
+	case e := <-events:
+		if e.Type != EventExpired || e.WorkerID != expiredID {
+			t.Errorf("应该收到 WorkerID %d 的 expired 事件, 实际值: %+v", expiredID, e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("等待 expired 事件超时")
+	}
+
+	// 有效租约不受影响
+	if err := gen.Renew(liveID, liveToken); err != nil {
+		t.Errorf("清理后有效租约 Renew() 失败: %v", err)
+	}
+
+	// 再次清理没有可回收的 ID
+	result, err = gen.Sweep(ctx)
+	if err != nil {
+		t.Fatalf("Sweep() 失败: %v", err)
+	}
+	if len(result.Reclaimed) != 0 {
+		t.Errorf("不应该有可清理的 ID, 实际值: %v", result.Reclaimed)
+	}
+}
+
+func TestRedisGenerator_RunSweeper(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	expireAt := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	if err := client.HSet(ctx, gen.getTokenKey(), "1", "abcdefghijklmnopqrstuv:"+expireAt).Err(); err != nil {
+		t.Fatalf("写入过期 Token 失败: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		gen.RunSweeper(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		n, err := client.HLen(context.Background(), gen.getTokenKey()).Result()
+		if err != nil {
+			t.Fatalf("获取 Token 数量失败: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("清理器未清理过期 Token")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ctx 结束后 RunSweeper 应该返回")
+	}
+}
@@ -0,0 +1,125 @@
+package workerid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// waitRetryInterval GetIDWait 在没有收到事件时的重试间隔，
+// 未运行清理器时 ID 过期不会发布事件，需要依靠定期重试发现
+const waitRetryInterval = 5 * time.Second
+
+// PoolEvent 池变更事件，通过 Redis Pub/Sub 在同一集群的所有实例间广播
+type PoolEvent struct {
+	Type     EventType `json:"type"`
+	Cluster  string    `json:"cluster"`
+	WorkerID int64     `json:"worker_id"`
+	Time     time.Time `json:"time"`
+}
+
+// Watch 订阅池变更事件（acquired、released、revoked、expired），ctx 结束时关闭返回的 channel。
+// 事件基于 Pub/Sub 投递，断线期间的事件会丢失。
+func (g *RedisGenerator) Watch(ctx context.Context) (<-chan PoolEvent, error) {
+	pubsub := g.redisClient.Subscribe(ctx, g.getEventChannel())
+	// 等待订阅确认，保证返回后发布的事件都能收到
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("subscribe failed: %w", err)
+	}
+
+	events := make(chan PoolEvent, 64)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var data struct {
+					Type     EventType `json:"type"`
+					WorkerID int64     `json:"worker_id"`
+					Time     int64     `json:"time"`
+				}
+				if err := json.Unmarshal([]byte(msg.Payload), &data); err != nil {
+					g.logger.WarnContext(ctx, "decode pool event failed", slog.Any("error", err))
+					continue
+				}
+				event := PoolEvent{
+					Type:     data.Type,
+					Cluster:  g.cluster,
+					WorkerID: g.composeID(data.WorkerID),
+					Time:     time.Unix(data.Time, 0),
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// GetIDWait 获取 WorkerID，池耗尽时等待其他 WorkerID 被释放、回收或过期后重试，直到 ctx 结束
+func (g *RedisGenerator) GetIDWait(ctx context.Context) (int64, string, error) {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var events <-chan PoolEvent
+	for {
+		workerID, token, err := g.GetIDContext(ctx)
+		if !errors.Is(err, ErrNoAvailableID) {
+			return workerID, token, err
+		}
+		if events == nil {
+			if events, err = g.Watch(watchCtx); err != nil {
+				return 0, "", err
+			}
+			// 订阅后立即重试，避免错过订阅前释放的 ID
+			continue
+		}
+
+		g.logger.DebugContext(ctx, "waiting for available worker ID")
+		subscribed, err := waitPoolChange(ctx, events)
+		if err != nil {
+			return 0, "", err
+		}
+		if !subscribed {
+			// 订阅已断开，下一轮重新订阅
+			events = nil
+		}
+	}
+}
+
+// waitPoolChange 等待有 ID 变为可用的事件或重试间隔到期，events 被关闭时返回 false
+func waitPoolChange(ctx context.Context, events <-chan PoolEvent) (bool, error) {
+	timer := time.NewTimer(waitRetryInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-timer.C:
+			return true, nil
+		case event, ok := <-events:
+			if !ok {
+				return false, ctx.Err()
+			}
+			if event.Type != EventAcquired {
+				return true, nil
+			}
+		}
+	}
+}
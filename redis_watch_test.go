@@ -0,0 +1,126 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRedisGenerator_Watch(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := gen.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() 失败: %v", err)
+	}
+
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if err := gen.Release(workerID, token); err != nil {
+		t.Fatalf("Release() 失败: %v", err)
+	}
+	workerID2, _, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if err := gen.Revoke(ctx, workerID2, "alice", "test"); err != nil {
+		t.Fatalf("Revoke() 失败: %v", err)
+	}
+
+	want := []struct {
+		typ      EventType
+		workerID int64
+	}{
+		{EventAcquired, workerID},
+		{EventReleased, workerID},
+		{EventAcquired, workerID2},
+		{EventRevoked, workerID2},
+	}
+	for i, w := range want {
+		select {
+		case e := <-events:
+			if e.Type != w.typ || e.WorkerID != w.workerID {
+				t.Errorf("第 %d 个事件应该为 %s %d, 实际值: %s %d", i, w.typ, w.workerID, e.Type, e.WorkerID)
+			}
+			if e.Cluster != "test-cluster" || e.Time.IsZero() {
+				t.Errorf("第 %d 个事件信息不完整: %+v", i, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("等待第 %d 个事件超时", i)
+		}
+	}
+
+	// ctx 结束后关闭 channel
+	cancel()
+	select {
+	case _, ok := <-events:
+		for ok {
+			_, ok = <-events
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ctx 结束后 channel 应该被关闭")
+	}
+}
+
+func TestRedisGenerator_GetIDWait(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(1))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+	if _, _, err := gen.GetID(); err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+
+	// 池耗尽时等待到 ctx 超时
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, _, err := gen.GetIDWait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("池耗尽时 GetIDWait() 应该返回 DeadlineExceeded, 实际值: %v", err)
+	}
+
+	// 释放后等待者立即获取到 ID
+	type result struct {
+		workerID int64
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		id, _, err := gen.GetIDWait(context.Background())
+		done <- result{id, err}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if err := gen.Release(workerID, token); err != nil {
+		t.Fatalf("Release() 失败: %v", err)
+	}
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			t.Fatalf("GetIDWait() 失败: %v", r.err)
+		}
+		if r.workerID != workerID {
+			t.Errorf("GetIDWait() 应该获取到释放的 ID %d, 实际值: %d", workerID, r.workerID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("释放后 GetIDWait() 应该立即返回")
+	}
+}
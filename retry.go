@@ -0,0 +1,121 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RetryPolicy 访问 Redis 出现临时错误时的重试策略，零值表示不重试
+type RetryPolicy struct {
+	// MaxAttempts 最大尝试次数（包含第一次），不大于 1 时不重试
+	MaxAttempts int
+	// InitialBackoff 第一次重试前的等待时间
+	InitialBackoff time.Duration
+	// MaxBackoff 等待时间的上限，0 表示不限制
+	MaxBackoff time.Duration
+	// Multiplier 每次重试后等待时间的倍数，不大于 1 时使用 2
+	Multiplier float64
+	// Jitter 等待时间的随机抖动比例 [0, 1]，例如 0.2 表示在 ±20% 范围内随机，避免多个实例同时重试
+	Jitter float64
+	// Retryable 判断错误是否可以重试，为 nil 时使用 IsRetryable
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy 返回默认的重试策略：最多尝试 4 次，等待时间从 100ms 开始翻倍，最长 2s，抖动 ±20%
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// IsRetryable 判断错误是否为可以重试的临时错误。
+// 预定义错误（如 ErrTokenMismatch、ErrTokenExpired）和 context 结束都不可重试，
+// Redis 返回的错误中只有 LOADING、READONLY、TRYAGAIN、CLUSTERDOWN、MASTERDOWN 可以重试，其余错误（如网络错误）均可重试
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, ErrNoAvailableID), errors.Is(err, ErrInvalidWorkerID), errors.Is(err, ErrTokenMismatch),
+		errors.Is(err, ErrTokenExpired), errors.Is(err, ErrNotAssigned), errors.Is(err, ErrInvalidToken),
+		errors.Is(err, ErrWorkerIDInUse), errors.Is(err, ErrCircuitOpen), errors.Is(err, ErrNotReserved),
+		errors.Is(err, ErrPoolMismatch):
+		return false
+	}
+
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		for _, prefix := range []string{"LOADING ", "READONLY ", "TRYAGAIN ", "CLUSTERDOWN ", "MASTERDOWN "} {
+			if strings.HasPrefix(redisErr.Error(), prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// backoff 返回第 attempt 次重试（从 1 开始）前的等待时间
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= multiplier
+		if p.MaxBackoff > 0 && d >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		d *= 1 + jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(d)
+}
+
+// retry 按重试策略执行 fn，直到成功、遇到不可重试的错误、达到最大尝试次数或 ctx 结束，attempt 从 1 开始。
+// 开启降级模式时，断路器打开后不再访问 Redis，直接返回 ErrCircuitOpen
+func (g *RedisGenerator) retry(ctx context.Context, op Operation, fn func(attempt int) error) error {
+	policy := g.retryPolicy
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		if !g.breaker.allow() {
+			return ErrCircuitOpen
+		}
+		err := fn(attempt)
+		g.breaker.record(err)
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		wait := policy.backoff(attempt)
+		g.logger.WarnContext(ctx, string(op)+" failed, retrying", slog.Int("attempt", attempt),
+			slog.Duration("backoff", wait), slog.Any("error", err))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
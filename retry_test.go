@@ -0,0 +1,161 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{io.EOF, true},
+		{fmt.Errorf("renew failed: %w", io.ErrUnexpectedEOF), true},
+		{context.Canceled, false},
+		{context.DeadlineExceeded, false},
+		{ErrTokenMismatch, false},
+		{ErrTokenExpired, false},
+		{ErrNotAssigned, false},
+		{ErrNoAvailableID, false},
+		{ErrNotReserved, false},
+		{fmt.Errorf("get ID failed: %w", redisError("LOADING Redis is loading the dataset in memory")), true},
+		{fmt.Errorf("get ID failed: %w", redisError("READONLY You can't write against a read only replica.")), true},
+		{fmt.Errorf("get ID failed: %w", redisError("ERR unknown command")), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, 期望: %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// redisError 模拟 Redis 返回的错误
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+func (redisError) RedisError()     {}
+
+var _ redis.Error = redisError("")
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		if got := policy.backoff(attempt); got != want {
+			t.Errorf("第 %d 次重试的等待时间应该为 %v, 实际值: %v", attempt, want, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(1); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("抖动后的等待时间应在 [50ms, 150ms] 范围内, 实际值: %v", got)
+		}
+	}
+}
+
+func TestRedisGenerator_WithRetryPolicy(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	var retried atomic.Int32
+	policy := RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: 20 * time.Millisecond,
+		Retryable: func(err error) bool {
+			retried.Add(1)
+			return IsRetryable(err)
+		},
+	}
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2), WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	workerID, token, err := gen.GetID()
+	if err != nil {
+		t.Fatalf("GetID() 失败: %v", err)
+	}
+
+	// Redis 暂时不可用时重试直到恢复
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+	time.AfterFunc(100*time.Millisecond, func() { mr.SetError("") })
+	if err := gen.Renew(workerID, token); err != nil {
+		t.Fatalf("Redis 恢复后 Renew 应该成功: %v", err)
+	}
+	if n := retried.Load(); n < 2 {
+		t.Errorf("Renew 应该至少重试 1 次, 实际判断次数: %d", n)
+	}
+
+	// 不可重试的错误立即返回
+	retried.Store(0)
+	if err := gen.Renew(workerID, "abcdefghijklmnopqrstuv"); !errors.Is(err, ErrTokenMismatch) {
+		t.Errorf("Renew 应该返回 ErrTokenMismatch, 实际值: %v", err)
+	}
+	if n := retried.Load(); n != 1 {
+		t.Errorf("ErrTokenMismatch 不应重试, 实际判断次数: %d", n)
+	}
+
+	// 达到最大尝试次数后返回最后一次的错误
+	gen.retryPolicy.MaxAttempts = 3
+	retried.Store(0)
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+	if err := gen.Release(workerID, token); err == nil || !IsRetryable(err) {
+		t.Errorf("Redis 不可用时 Release 应该返回可重试的错误, 实际值: %v", err)
+	}
+	if n := retried.Load(); n != 2 {
+		t.Errorf("最多尝试 3 次时应判断 2 次, 实际判断次数: %d", n)
+	}
+	mr.SetError("")
+
+	// ctx 结束时停止重试
+	gen.retryPolicy.MaxAttempts = 100
+	gen.retryPolicy.InitialBackoff = time.Hour
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, _, err := gen.GetIDContext(ctx); err == nil {
+		t.Error("Redis 不可用时 GetID 应该返回错误")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ctx 结束后应该立即停止重试, 实际耗时: %v", elapsed)
+	}
+}
+
+func TestRedisGenerator_RetryInit(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	// 启动时 Redis 暂时不可用，写入预留 ID 和初始化池都应该重试直到恢复
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+	time.AfterFunc(100*time.Millisecond, func() { mr.SetError("") })
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2),
+		WithReservedRange(0, 0), WithRetryPolicy(RetryPolicy{MaxAttempts: 10, InitialBackoff: 20 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("Redis 恢复后创建 RedisGenerator 应该成功: %v", err)
+	}
+	ctx := context.Background()
+	if reserved := client.HKeys(ctx, gen.getReservedKey()).Val(); len(reserved) != 1 || reserved[0] != "0" {
+		t.Errorf("预留 ID 应该已写入, 实际值: %v", reserved)
+	}
+	if n := client.ZCard(ctx, gen.getIDsKey()).Val(); n != 3 {
+		t.Errorf("池中应有 3 个 ID, 实际值: %d", n)
+	}
+}
@@ -0,0 +1,256 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrSessionClosed Session 已开始关闭，不能再使用 WorkerID
+var ErrSessionClosed = errors.New("session closed")
+
+// SessionOptions Session 的配置，零值使用默认配置
+type SessionOptions struct {
+	// Signals 收到这些信号时关闭 Session，为 nil 时使用 SIGINT 和 SIGTERM，为空切片时不监听信号
+	Signals []os.Signal
+	// RenewInterval 续期间隔，默认为租约时间的 1/3，无法获取租约时间时为 30 秒
+	RenewInterval time.Duration
+	// ReleaseTimeout 由 ctx 或信号触发关闭时，等待进行中的操作和释放 WorkerID 的最长时间，默认为 10 秒
+	ReleaseTimeout time.Duration
+	// Logger 记录续期失败和关闭过程的日志，为 nil 时使用 RedisGenerator 的 logger 或不输出日志
+	Logger *slog.Logger
+}
+
+// Session 持有一个 WorkerID 并在后台续期，直到 ctx 结束、收到信号、调用 Shutdown 或租约丢失。
+// 使用 WorkerID 生成业务 ID 的操作应通过 Do 执行，关闭时等待进行中的操作结束后再释放 WorkerID，
+// 避免释放后仍在使用旧的 WorkerID
+type Session struct {
+	gen      ContextGenerator
+	workerID int64
+	token    string
+	opts     SessionOptions
+	logger   *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   context.CancelFunc
+
+	mu      sync.Mutex
+	closing bool
+	err     error
+	active  sync.WaitGroup
+
+	started  bool
+	released chan struct{}
+	result   error
+}
+
+// NewSession 获取一个 WorkerID 并开始续期。ctx 结束或收到 opts.Signals 中的信号时自动关闭 Session 并释放 WorkerID
+func NewSession(ctx context.Context, gen ContextGenerator, opts SessionOptions) (*Session, error) {
+	if opts.Signals == nil {
+		opts.Signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	if opts.ReleaseTimeout <= 0 {
+		opts.ReleaseTimeout = 10 * time.Second
+	}
+	if opts.RenewInterval <= 0 {
+		opts.RenewInterval = 30 * time.Second
+		if lease := leaseTime(gen); lease > 0 {
+			opts.RenewInterval = lease / 3
+		}
+	}
+	logger := opts.Logger
+	if logger == nil {
+		if g, ok := gen.(*RedisGenerator); ok {
+			logger = g.logger
+		}
+	}
+	logger = newLogger(logger, "").With(slog.String("component", "session"))
+
+	workerID, token, err := gen.GetIDContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := context.CancelFunc(func() {})
+	if len(opts.Signals) > 0 {
+		ctx, stop = signal.NotifyContext(ctx, opts.Signals...)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Session{
+		gen:      gen,
+		workerID: workerID,
+		token:    token,
+		opts:     opts,
+		logger:   logger.With(slog.Int64("worker_id", workerID)),
+		ctx:      ctx,
+		cancel:   cancel,
+		stop:     stop,
+		released: make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// leaseTime 返回 gen 的租约时间，无法获取时返回 0
+func leaseTime(gen ContextGenerator) time.Duration {
+	switch g := gen.(type) {
+	case *RedisGenerator:
+		return time.Duration(g.leaseSeconds) * time.Second
+	case *PooledGenerator:
+		return time.Duration(g.gen.leaseSeconds) * time.Second
+	}
+	return 0
+}
+
+// WorkerID 返回 Session 持有的 WorkerID
+func (s *Session) WorkerID() int64 {
+	return s.workerID
+}
+
+// Token 返回 Session 持有的租约的 Token
+func (s *Session) Token() string {
+	return s.token
+}
+
+// Context 返回 Session 的 context，Session 开始关闭或租约丢失时结束
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// Done 返回在 WorkerID 释放（或租约丢失后放弃释放）时关闭的 channel
+func (s *Session) Done() <-chan struct{} {
+	return s.released
+}
+
+// Err 返回租约丢失的原因，租约未丢失时返回 nil
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Do 使用 WorkerID 执行 fn，Session 关闭时等待进行中的 fn 结束后再释放 WorkerID。
+// Session 已开始关闭时返回 ErrSessionClosed，租约已丢失时返回丢失的原因
+func (s *Session) Do(fn func(workerID int64) error) error {
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+		return s.err
+	}
+	if s.closing {
+		s.mu.Unlock()
+		return ErrSessionClosed
+	}
+	s.active.Add(1)
+	s.mu.Unlock()
+
+	defer s.active.Done()
+	return fn(s.workerID)
+}
+
+// Shutdown 停止续期，等待进行中的 Do 结束后释放 WorkerID。ctx 结束时不再等待进行中的 Do，
+// 在 ReleaseTimeout 内释放 WorkerID。可以多次调用，之后的调用等待第一次关闭完成或 ctx 结束
+func (s *Session) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	started := s.started
+	s.started = true
+	s.mu.Unlock()
+	if !started {
+		s.result = s.shutdown(ctx)
+		close(s.released)
+		return s.result
+	}
+	select {
+	case <-s.released:
+		return s.result
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RegisterOnShutdown 在 srv.Shutdown 开始时关闭 Session，最多等待 ReleaseTimeout
+func (s *Session) RegisterOnShutdown(srv *http.Server) {
+	srv.RegisterOnShutdown(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), s.opts.ReleaseTimeout)
+		defer cancel()
+		if err := s.Shutdown(ctx); err != nil {
+			s.logger.Warn("shutdown session failed", slog.Any("error", err))
+		}
+	})
+}
+
+// run 定期续期，ctx 结束时关闭 Session
+func (s *Session) run() {
+	ticker := time.NewTicker(s.opts.RenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			ctx, cancel := context.WithTimeout(context.Background(), s.opts.ReleaseTimeout)
+			defer cancel()
+			if err := s.Shutdown(ctx); err != nil {
+				s.logger.Warn("shutdown session failed", slog.Any("error", err))
+			}
+			return
+		case <-ticker.C:
+			s.renew()
+		}
+	}
+}
+
+// renew 续期租约，租约丢失时结束 Session 的 context，临时错误留到下一次续期重试
+func (s *Session) renew() {
+	err := s.gen.RenewContext(s.ctx, s.workerID, s.token)
+	if err == nil || s.ctx.Err() != nil {
+		return
+	}
+	if !isLeaseLost(err) {
+		s.logger.Warn("renew session lease failed", slog.Any("error", err))
+		return
+	}
+	s.logger.Error("session lease lost", slog.Any("error", err))
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+	s.cancel()
+}
+
+// shutdown 拒绝新的 Do，等待进行中的 Do 结束后释放 WorkerID
+func (s *Session) shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	lost := s.err
+	s.mu.Unlock()
+	s.cancel()
+	defer s.stop()
+
+	drained := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		s.logger.Warn("in-flight operations not finished before release", slog.Any("error", ctx.Err()))
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), s.opts.ReleaseTimeout)
+		defer cancel()
+	}
+
+	if lost != nil {
+		return nil
+	}
+	if err := s.gen.ReleaseContext(ctx, s.workerID, s.token); err != nil && !isLeaseLost(err) {
+		return err
+	}
+	s.logger.Info("session closed")
+	return nil
+}
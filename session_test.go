@@ -0,0 +1,220 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestSession_Shutdown(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	session, err := NewSession(context.Background(), gen, SessionOptions{
+		Signals:       []os.Signal{},
+		RenewInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewSession() 失败: %v", err)
+	}
+
+	// 后台续期
+	time.Sleep(50 * time.Millisecond)
+	if err := gen.Renew(session.WorkerID(), session.Token()); err != nil {
+		t.Fatalf("Session 持有的租约应该有效: %v", err)
+	}
+
+	// 关闭时等待进行中的操作结束后再释放
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	go session.Do(func(workerID int64) error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		close(finished)
+		return nil
+	})
+	<-started
+	if err := session.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() 失败: %v", err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Error("Shutdown 应该等待进行中的 Do 结束")
+	}
+	if err := gen.Renew(session.WorkerID(), session.Token()); !errors.Is(err, ErrNotAssigned) {
+		t.Errorf("关闭后 WorkerID 应该已释放, 实际值: %v", err)
+	}
+	if err := session.Do(func(int64) error { return nil }); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("关闭后 Do 应该返回 ErrSessionClosed, 实际值: %v", err)
+	}
+	if session.Context().Err() == nil {
+		t.Error("关闭后 Session 的 context 应该结束")
+	}
+	if err := session.Shutdown(context.Background()); err != nil {
+		t.Errorf("重复调用 Shutdown 应该返回 nil, 实际值: %v", err)
+	}
+}
+
+func TestSession_Signal(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	session, err := NewSession(context.Background(), gen, SessionOptions{Signals: []os.Signal{os.Interrupt}})
+	if err != nil {
+		t.Fatalf("NewSession() 失败: %v", err)
+	}
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("查找进程失败: %v", err)
+	}
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Skipf("当前平台不支持发送信号: %v", err)
+	}
+	select {
+	case <-session.Done():
+	case <-time.After(time.Second):
+		t.Fatal("收到信号后 Session 应该关闭")
+	}
+	if err := gen.Renew(session.WorkerID(), session.Token()); !errors.Is(err, ErrNotAssigned) {
+		t.Errorf("收到信号后 WorkerID 应该已释放, 实际值: %v", err)
+	}
+}
+
+func TestSession_LeaseLost(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	session, err := NewSession(context.Background(), gen, SessionOptions{
+		Signals:       []os.Signal{},
+		RenewInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewSession() 失败: %v", err)
+	}
+	if err := gen.Revoke(context.Background(), session.WorkerID(), "admin", "test"); err != nil {
+		t.Fatalf("Revoke() 失败: %v", err)
+	}
+
+	select {
+	case <-session.Done():
+	case <-time.After(time.Second):
+		t.Fatal("租约丢失后 Session 应该关闭")
+	}
+	if !isLeaseLost(session.Err()) {
+		t.Errorf("Err() 应该返回租约丢失的原因, 实际值: %v", session.Err())
+	}
+	if err := session.Do(func(int64) error { return nil }); !isLeaseLost(err) {
+		t.Errorf("租约丢失后 Do 应该返回丢失的原因, 实际值: %v", err)
+	}
+}
+
+func TestSession_ReconcileConflict(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	var mu sync.Mutex
+	var events []LeaseEvent
+	record := func(e LeaseEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}
+	staticID := int64(0)
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2),
+		WithDegradedMode(DegradedMode{StaticWorkerID: &staticID}),
+		WithHooks(Hooks{OnRenewFailed: record, OnLost: record}))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+
+	// 降级期间使用未确认的 WorkerID 创建 Session
+	mr.SetError(loadingError)
+	session, err := NewSession(context.Background(), gen, SessionOptions{
+		Signals:       []os.Signal{},
+		RenewInterval: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("降级模式下 NewSession() 失败: %v", err)
+	}
+	if !gen.IsUnverified(session.WorkerID()) {
+		t.Fatalf("Session 应该持有未确认的 WorkerID")
+	}
+
+	// Redis 恢复前该 ID 被其他实例分配，续期时确认失败
+	mr.SetError("")
+	peer, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	if id, _, err := peer.GetID(); err != nil || id != session.WorkerID() {
+		t.Fatalf("其他实例应该分配到 WorkerID %d, 实际值: %d, 错误: %v", session.WorkerID(), id, err)
+	}
+
+	select {
+	case <-session.Done():
+	case <-time.After(time.Second):
+		t.Fatal("确认失败后 Session 应该关闭")
+	}
+	if !errors.Is(session.Err(), ErrWorkerIDInUse) {
+		t.Errorf("Err() 应该返回 ErrWorkerIDInUse, 实际值: %v", session.Err())
+	}
+	if err := session.Do(func(int64) error { return nil }); !errors.Is(err, ErrWorkerIDInUse) {
+		t.Errorf("确认失败后 Do 应该返回 ErrWorkerIDInUse, 实际值: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 || events[0].Type != EventLost || !errors.Is(events[0].Err, ErrWorkerIDInUse) {
+		t.Errorf("确认失败应该只触发 lost 事件, 实际值: %+v", events)
+	}
+}
+
+func TestSession_RegisterOnShutdown(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gen, err := NewRedisGenerator(client, "test-cluster", WithWorkerBits(2))
+	if err != nil {
+		t.Fatalf("创建 RedisGenerator 失败: %v", err)
+	}
+	session, err := NewSession(context.Background(), gen, SessionOptions{Signals: []os.Signal{}})
+	if err != nil {
+		t.Fatalf("NewSession() 失败: %v", err)
+	}
+
+	srv := &http.Server{}
+	session.RegisterOnShutdown(srv)
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("http.Server.Shutdown() 失败: %v", err)
+	}
+	select {
+	case <-session.Done():
+	case <-time.After(time.Second):
+		t.Fatal("http.Server 关闭后 Session 应该关闭")
+	}
+	if err := gen.Renew(session.WorkerID(), session.Token()); !errors.Is(err, ErrNotAssigned) {
+		t.Errorf("http.Server 关闭后 WorkerID 应该已释放, 实际值: %v", err)
+	}
+}